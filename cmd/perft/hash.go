@@ -0,0 +1,64 @@
+package main
+
+import "sync/atomic"
+
+// https://www.chessprogramming.org/Perft#Hashing
+
+// hashTable stores the node counts of subtrees that have already been counted,
+// keyed by the Zobrist hash of the position at the root of the subtree and its
+// depth, so that transpositions are only counted once.
+//
+// The table is shared by every goroutine counting in parallel, without locking:
+// each slot is two words, read and written atomically, with key holding the key
+// xor count. A slot that's read half way through being written won't match the
+// key, and is treated as empty.
+type hashTable struct {
+	slots []hashSlot
+	mask  uint64
+}
+
+type hashSlot struct {
+	key   uint64
+	count uint64
+}
+
+// newHashTable returns a new hash table taking up at most the given number of
+// megabytes, rounded down to a power of two entries.
+func newHashTable(megabytes int) *hashTable {
+	n := uint64(megabytes) * 1024 * 1024 / 16
+	size := uint64(1)
+	for size*2 <= n {
+		size *= 2
+	}
+	return &hashTable{
+		slots: make([]hashSlot, size),
+		mask:  size - 1,
+	}
+}
+
+// key mixes the depth into the hash, so that counts for the same position at
+// different depths are stored separately.
+func key(hash uint64, depth uint) uint64 {
+	return hash ^ uint64(depth)*0x9e3779b97f4a7c15
+}
+
+// probe returns the node count for the position with the given hash to depth,
+// if it's in the table.
+func (t *hashTable) probe(hash uint64, depth uint) (uint64, bool) {
+	k := key(hash, depth)
+	s := &t.slots[k&t.mask]
+	count := atomic.LoadUint64(&s.count)
+	if atomic.LoadUint64(&s.key)^count != k {
+		return 0, false
+	}
+	return count, true
+}
+
+// store records the node count for the position with the given hash to depth,
+// replacing whatever was stored in its slot before.
+func (t *hashTable) store(hash uint64, depth uint, count uint64) {
+	k := key(hash, depth)
+	s := &t.slots[k&t.mask]
+	atomic.StoreUint64(&s.key, k^count)
+	atomic.StoreUint64(&s.count, count)
+}
@@ -5,28 +5,52 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/GeorgeBills/chess/engine"
 )
 
 func main() {
-	depth := flag.Uint("depth", 1, "depth to generate moves to")
+	depth := flag.Uint("depth", 1, "depth to generate moves to (in suite mode, the deepest depth to check)")
 	fen := flag.String("fen", engine.InitialBoardFEN, "FEN to start with")
 	divide := flag.Bool("divide", false, "whether or not to output node count divided by initial moves")
 	validate := flag.Bool("validate", false, "whether or not to validate each board state")
+	parallel := flag.Int("parallel", 1, "number of goroutines to split the initial moves between")
+	hash := flag.Int("hash", 0, "perft hash table size in megabytes, or 0 for no hash table")
+	suite := flag.String("suite", "", "EPD-style file of positions with expected node counts per depth to check")
 
 	flag.Parse()
 
+	p := &perfter{validate: *validate, workers: *parallel}
+	if *hash > 0 {
+		p.table = newHashTable(*hash)
+	}
+
+	if *suite != "" {
+		passed, total, err := runSuite(*suite, *depth, p)
+		if err != nil {
+			fatal(err)
+		}
+		if passed != total {
+			os.Exit(1)
+		}
+		return
+	}
+
 	b, err := engine.NewBoardFromFEN(strings.NewReader(*fen))
 	if err != nil {
 		fatal(fmt.Errorf("error parsing FEN: %w", err))
 	}
-	g := engine.NewGame(b)
 	start := time.Now()
 	var n uint64 = 0
 	if *depth > 0 {
-		n = perft(g, *depth, *validate, *divide)
+		for _, r := range p.divide(b, *depth, *divide) {
+			if *divide {
+				fmt.Printf("%s\t%d\t%s\n", r.move.SAN(), r.nodes, r.fen)
+			}
+			n += r.nodes
+		}
 	}
 	elapsed := time.Since(start)
 	fmt.Printf("%d nodes, took %s\n", n, elapsed)
@@ -37,31 +61,116 @@ func fatal(v error) {
 	os.Exit(1)
 }
 
-func perft(g *engine.Game, depth uint, validate, divide bool) uint64 {
-	var ret uint64
+// perfter counts the leaf nodes of the tree of legal moves.
+type perfter struct {
+	validate bool       // validate every board state, failing out on the first invalid one
+	workers  int        // number of goroutines to split the initial moves between
+	table    *hashTable // node counts of subtrees that have already been counted, if not nil
+}
+
+// rootResult is the node count for one of the initial moves.
+type rootResult struct {
+	move  engine.Move
+	nodes uint64
+	fen   string // the FEN after the move, if asked for
+}
+
+// divide returns the node count to depth for each of the legal moves from b,
+// in the order they're generated. If fen is true each result includes the FEN
+// after the move. The moves are split between p.workers goroutines, each
+// counting on its own copy of the board, since making moves modifies it.
+func (p *perfter) divide(b *engine.Board, depth uint, fen bool) []rootResult {
+	moves, _ := b.GenerateLegalMoves(nil)
+	results := make([]rootResult, len(moves))
+
+	workers := p.workers
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(moves) {
+		workers = len(moves)
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		bb := *b
+		g := engine.NewGame(&bb)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i] = p.root(g, moves[i], depth, fen)
+			}
+		}()
+	}
+	for i := range moves {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	return results
+}
+
+// root returns the node count to depth for making move in g.
+func (p *perfter) root(g *engine.Game, move engine.Move, depth uint, fen bool) rootResult {
+	p.makeMove(g, move)
+	defer g.UnmakeMove()
+	r := rootResult{move: move, nodes: 1}
+	if depth > 1 {
+		r.nodes = p.perft(g, depth-1)
+	}
+	if fen {
+		r.fen = g.FEN()
+	}
+	return r
+}
+
+// perft returns the node count to depth for g.
+func (p *perfter) perft(g *engine.Game, depth uint) uint64 {
+	if p.table != nil {
+		if n, ok := p.table.probe(g.Hash(), depth); ok {
+			return n
+		}
+	}
+
 	moves := make([]engine.Move, 0, 32)
 	moves, _ = g.GenerateLegalMoves(moves)
-	for _, move := range moves {
-		fen := ""
-		if validate {
-			fen = g.FEN()
-		}
-		g.MakeMove(move)
-		if validate {
-			err := g.Validate()
-			if err != nil {
-				fatal(fmt.Errorf("move %s on board '%v' results in an invalid board: %v", move.SAN(), fen, err))
+
+	var ret uint64
+	if depth == 1 && !p.validate {
+		// "bulk counting": no need to make moves we won't look past
+		ret = uint64(len(moves))
+	} else {
+		for _, move := range moves {
+			p.makeMove(g, move)
+			var n uint64 = 1
+			if depth > 1 {
+				n = p.perft(g, depth-1)
 			}
+			ret += n
+			g.UnmakeMove()
 		}
-		var n uint64 = 1
-		if depth > 1 {
-			n = perft(g, depth-1, validate, false)
-		}
-		if divide {
-			fmt.Printf("%s\t%d\t%s\n", move.SAN(), n, g.FEN())
-		}
-		ret += n
-		g.UnmakeMove()
+	}
+
+	if p.table != nil {
+		p.table.store(g.Hash(), depth, ret)
 	}
 	return ret
 }
+
+// makeMove makes move in g, validating the board afterwards if asked to.
+func (p *perfter) makeMove(g *engine.Game, move engine.Move) {
+	fen := ""
+	if p.validate {
+		fen = g.FEN()
+	}
+	g.MakeMove(move)
+	if p.validate {
+		err := g.Validate()
+		if err != nil {
+			fatal(fmt.Errorf("move %s on board '%v' results in an invalid board: %v", move.SAN(), fen, err))
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/GeorgeBills/chess/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// perft is a plain perft, without any of the tricks perfter uses, to check
+// perfter against.
+func perft(g *engine.Game, depth uint) uint64 {
+	moves, _ := g.GenerateLegalMoves(nil)
+	if depth == 1 {
+		return uint64(len(moves))
+	}
+	var n uint64
+	for _, move := range moves {
+		g.MakeMove(move)
+		n += perft(g, depth-1)
+		g.UnmakeMove()
+	}
+	return n
+}
+
+func TestDivide(t *testing.T) {
+	positions := []struct {
+		name  string
+		fen   string
+		depth uint
+	}{
+		{"initial", engine.InitialBoardFEN, 4},
+		{"kiwipete", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", 3},
+		{"position 3", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", 4},
+		{"position 4", "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1", 3},
+		{"chess960", "bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w HFhf - 2 9", 3},
+	}
+	perfters := []struct {
+		name     string
+		workers  int
+		megabyte int
+		validate bool
+	}{
+		{"serial", 1, 0, false},
+		{"validating", 1, 0, true},
+		{"parallel", 4, 0, false},
+		{"hashed", 1, 1, false},
+		{"parallel and hashed", 4, 1, false},
+	}
+	for _, pos := range positions {
+		b, err := engine.NewBoardFromFEN(strings.NewReader(pos.fen))
+		require.NoError(t, err)
+
+		// the expected count for each move at the root
+		expected := make(map[string]uint64)
+		g := engine.NewGame(b)
+		moves, _ := g.GenerateLegalMoves(nil)
+		for _, move := range moves {
+			g.MakeMove(move)
+			expected[move.SAN()] = 1
+			if pos.depth > 1 {
+				expected[move.SAN()] = perft(g, pos.depth-1)
+			}
+			g.UnmakeMove()
+		}
+
+		for _, pp := range perfters {
+			t.Run(fmt.Sprintf("%s %s", pos.name, pp.name), func(t *testing.T) {
+				p := &perfter{validate: pp.validate, workers: pp.workers}
+				if pp.megabyte > 0 {
+					p.table = newHashTable(pp.megabyte)
+				}
+
+				actual := make(map[string]uint64)
+				for _, r := range p.divide(b, pos.depth, false) {
+					actual[r.move.SAN()] = r.nodes
+				}
+				assert.Equal(t, expected, actual)
+			})
+		}
+	}
+}
+
+func TestHashTable(t *testing.T) {
+	table := newHashTable(1)
+
+	_, ok := table.probe(0x1234, 3)
+	assert.False(t, ok)
+
+	table.store(0x1234, 3, 8902)
+	n, ok := table.probe(0x1234, 3)
+	assert.True(t, ok)
+	assert.EqualValues(t, 8902, n)
+
+	_, ok = table.probe(0x1234, 4)
+	assert.False(t, ok, "counts to other depths should be stored separately")
+	_, ok = table.probe(0x1235, 3)
+	assert.False(t, ok)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GeorgeBills/chess/engine"
+)
+
+// suitePosition is a position from a perft suite, with the expected node count
+// at each depth it gives.
+type suitePosition struct {
+	fen      string
+	board    *engine.Board
+	expected map[uint]uint64
+}
+
+// parseSuiteLine parses a line of a perft suite: a FEN followed by the expected
+// node count at one or more depths, each after a semicolon, e.g.
+//
+//	rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1 ;D1 20 ;D2 400
+//
+// The half move clock and full move number may be left out of the FEN, as in
+// EPD, in which case they're taken to be 0 and 1. At least one depth must be
+// given.
+func parseSuiteLine(line string) (suitePosition, error) {
+	parts := strings.Split(line, ";")
+
+	fields := strings.Fields(parts[0])
+	switch len(fields) {
+	case 4:
+		fields = append(fields, "0", "1")
+	case 6:
+	default:
+		return suitePosition{}, fmt.Errorf("expecting 4 or 6 FEN fields, found %d", len(fields))
+	}
+	fen := strings.Join(fields, " ")
+	b, err := engine.NewBoardFromFEN(strings.NewReader(fen))
+	if err != nil {
+		return suitePosition{}, fmt.Errorf("error parsing FEN: %w", err)
+	}
+	p := suitePosition{fen: fen, board: b, expected: make(map[uint]uint64)}
+
+	for _, part := range parts[1:] {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue // trailing semicolon
+		}
+		if len(fields) != 2 || !strings.HasPrefix(fields[0], "D") {
+			return suitePosition{}, fmt.Errorf("expecting 'D<depth> <nodes>', found '%s'", strings.TrimSpace(part))
+		}
+		depth, err := strconv.ParseUint(fields[0][1:], 10, 8)
+		if err != nil {
+			return suitePosition{}, fmt.Errorf("error parsing depth: %w", err)
+		}
+		nodes, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return suitePosition{}, fmt.Errorf("error parsing node count: %w", err)
+		}
+		p.expected[uint(depth)] = nodes
+	}
+	if len(p.expected) == 0 {
+		return suitePosition{}, fmt.Errorf("expecting at least one 'D<depth> <nodes>'")
+	}
+
+	return p, nil
+}
+
+// runSuite checks the node count at every depth up to maxDepth for every
+// position in the named perft suite, outputting the result of each check and
+// returning how many passed out of how many were run. Blank lines and lines
+// starting with "#" are skipped.
+func runSuite(name string, maxDepth uint, p *perfter) (passed, total int, err error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	start := time.Now()
+	s := bufio.NewScanner(f)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		pos, err := parseSuiteLine(line)
+		if err != nil {
+			return passed, total, fmt.Errorf("%s: line %d: %w", name, n, err)
+		}
+
+		for depth := uint(1); depth <= maxDepth; depth++ {
+			expected, ok := pos.expected[depth]
+			if !ok {
+				continue
+			}
+			var nodes uint64
+			for _, r := range p.divide(pos.board, depth, false) {
+				nodes += r.nodes
+			}
+
+			total++
+			result := "FAIL"
+			if nodes == expected {
+				passed++
+				result = "pass"
+			}
+			fmt.Printf("%s D%-2d %12d %12d  %s\n", result, depth, nodes, expected, pos.fen)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return passed, total, err
+	}
+
+	fmt.Printf("%d/%d passed, took %s\n", passed, total, time.Since(start))
+	return passed, total, nil
+}
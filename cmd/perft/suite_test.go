@@ -0,0 +1,102 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSuiteLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		fen      string
+		expected map[uint]uint64
+	}{
+		{
+			"full FEN",
+			"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1 ;D1 20 ;D2 400",
+			"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+			map[uint]uint64{1: 20, 2: 400},
+		},
+		{
+			"EPD without move counters",
+			"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - ;D1 14",
+			"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+			map[uint]uint64{1: 14},
+		},
+		{
+			"trailing semicolon and extra spaces",
+			"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1;  D3   2812 ; D2 191;",
+			"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+			map[uint]uint64{2: 191, 3: 2812},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos, err := parseSuiteLine(tt.line)
+			require.NoError(t, err)
+			assert.Equal(t, tt.fen, pos.fen)
+			assert.NotNil(t, pos.board)
+			assert.Equal(t, tt.expected, pos.expected)
+		})
+	}
+}
+
+func TestParseSuiteLineInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		expected string
+	}{
+		{
+			"no depths",
+			"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+			"expecting at least one 'D<depth> <nodes>'",
+		},
+		{
+			"missing node count",
+			"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1 ;D1",
+			"expecting 'D<depth> <nodes>', found 'D1'",
+		},
+		{
+			"missing D",
+			"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1 ;1 20",
+			"expecting 'D<depth> <nodes>', found '1 20'",
+		},
+		{
+			"non-numeric depth",
+			"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1 ;Dx 20",
+			"error parsing depth: strconv.ParseUint: parsing \"x\": invalid syntax",
+		},
+		{
+			"non-numeric node count",
+			"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1 ;D1 twenty",
+			"error parsing node count: strconv.ParseUint: parsing \"twenty\": invalid syntax",
+		},
+		{
+			"too few FEN fields",
+			"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq ;D1 20",
+			"expecting 4 or 6 FEN fields, found 3",
+		},
+		{
+			"bad FEN",
+			"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR x KQkq - 0 1 ;D1 20",
+			"error parsing FEN: unexpected 'x', expecting [wb]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseSuiteLine(tt.line)
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
+
+func TestRunSuite(t *testing.T) {
+	passed, total, err := runSuite("testdata/perftsuite.epd", 3, &perfter{workers: 2, table: newHashTable(1)})
+	require.NoError(t, err)
+	assert.Equal(t, 18, total)
+	assert.Equal(t, total, passed)
+}
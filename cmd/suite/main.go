@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/GeorgeBills/chess/engine"
+	"github.com/GeorgeBills/chess/epd"
+)
+
+func main() {
+	depth := flag.Uint("depth", 0, "depth (in half moves) to search each position to")
+	movetime := flag.Duration("movetime", 0, "time to search each position for")
+	hash := flag.Int("hash", engine.DefaultTranspositionTableMegabytes, "transposition table size in megabytes")
+	verbose := flag.Bool("verbose", false, "whether or not to output the result for every position, rather than only failures")
+
+	flag.Parse()
+
+	if (*depth == 0) == (*movetime == 0) {
+		fatal(errors.New("exactly one of -depth or -movetime must be given"))
+	}
+	if *depth > 255 {
+		fatal(fmt.Errorf("depth %d too large", *depth))
+	}
+	if flag.NArg() == 0 {
+		fatal(fmt.Errorf("%s [-depth <n> | -movetime <duration>] <file.epd>...", os.Args[0]))
+	}
+
+	tt := engine.NewTranspositionTable(*hash)
+	search := func(g *engine.Game) engine.Move {
+		if *depth > 0 {
+			m, _ := g.BestMoveToDepth(uint8(*depth), nil, nil)
+			return m
+		}
+		statusch := make(chan engine.SearchStatus)
+		go func() {
+			for range statusch {
+				// drain; we only want the best move
+			}
+		}()
+		m, _ := g.BestMoveToMoveTime(*movetime, nil, statusch)
+		return m
+	}
+
+	start := time.Now()
+	var passed, total int
+	for _, name := range flag.Args() {
+		p, n, err := run(name, tt, search, *verbose)
+		if err != nil {
+			fatal(err)
+		}
+		passed += p
+		total += n
+	}
+	fmt.Printf("%d/%d passed, took %s\n", passed, total, time.Since(start))
+}
+
+// run runs search on every position with a best move ("bm") or avoid move
+// ("am") test in the named EPD file, returning how many passed out of how many
+// were run.
+func run(name string, tt *engine.TranspositionTable, search func(*engine.Game) engine.Move, verbose bool) (passed, total int, err error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	r := epd.NewReader(f)
+	for {
+		p, err := r.Read()
+		if err == io.EOF {
+			return passed, total, nil
+		}
+		if err != nil {
+			return passed, total, fmt.Errorf("%s: %w", name, err)
+		}
+		if p.Operands("bm") == nil && p.Operands("am") == nil {
+			continue
+		}
+
+		tt.Clear()
+		g := engine.NewGame(p.Board)
+		g.SetTranspositionTable(tt)
+		m := search(g)
+		san := m.SAN() // no move if the position is checkmate or stalemate
+		if m != 0 {
+			san = g.SAN(m)
+		}
+
+		ok, err := p.Check(m)
+		if err != nil {
+			return passed, total, fmt.Errorf("%s: %s: %w", name, p.ID(), err)
+		}
+
+		total++
+		result := "FAIL"
+		if ok {
+			passed++
+			result = "pass"
+		}
+		if verbose || !ok {
+			fmt.Printf("%-12s %s %-8s %s\n", p.ID(), result, san, expected(p))
+		}
+	}
+}
+
+// expected describes the moves expected for the position.
+func expected(p *epd.Position) string {
+	var parts []string
+	if bm := p.Operands("bm"); len(bm) > 0 {
+		parts = append(parts, "bm "+strings.Join(bm, " "))
+	}
+	if am := p.Operands("am"); len(am) > 0 {
+		parts = append(parts, "am "+strings.Join(am, " "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func fatal(v error) {
+	fmt.Fprintln(os.Stderr, v)
+	os.Exit(1)
+}
@@ -2,12 +2,19 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"log"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/GeorgeBills/chess"
 	"github.com/GeorgeBills/chess/engine"
+	"github.com/GeorgeBills/chess/polyglot"
 	"github.com/GeorgeBills/chess/uci"
 )
 
@@ -22,13 +29,35 @@ var errNoGame = errors.New("must initialise new game first")
 // newAdapter returns a new adapter.
 func newAdapter(logw io.Writer) *adapter {
 	return &adapter{
-		logger: log.New(logw, "adapter: ", log.LstdFlags),
+		logger:    log.New(logw, "adapter: ", log.LstdFlags),
+		tt:        engine.NewTranspositionTable(engine.DefaultTranspositionTableMegabytes),
+		bookDepth: defaultBookDepth,
+		rnd:       rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 }
 
 type adapter struct {
-	logger *log.Logger
-	game   *engine.Game
+	logger   *log.Logger
+	game     *engine.Game
+	tt       *engine.TranspositionTable
+	chess960 bool
+
+	// book is only consulted if ownBook is set, and then only up to and
+	// including full move bookDepth.
+	ownBook   bool
+	book      *polyglot.Book
+	bookDepth int
+	bookBest  bool
+	rnd       *rand.Rand
+
+	// ponder is the reply we expect to the best move from the last search,
+	// which we'll only report if the GUI has enabled pondering.
+	ponder        engine.Move
+	ponderEnabled bool
+
+	multiPV  int
+	threads  int
+	contempt int
 }
 
 func (a *adapter) Identify() (name, author string, other map[string]string) {
@@ -36,9 +65,224 @@ func (a *adapter) Identify() (name, author string, other map[string]string) {
 	return Name, Author, nil
 }
 
+// Names of the options we support.
+const (
+	optionHash      = "Hash"
+	optionClearHash = "Clear Hash"
+	optionThreads   = "Threads"
+	optionChess960  = "UCI_Chess960"
+	optionOwnBook   = "OwnBook"
+	optionBookFile  = "Book File"
+	optionBookDepth = "Book Depth"
+	optionBookMoves = "Book Moves"
+	optionPonder    = "Ponder"
+	optionMultiPV   = "MultiPV"
+	optionContempt  = "Contempt"
+)
+
+// maxHashMegabytes is the largest transposition table we'll allocate.
+const maxHashMegabytes = 4096
+
+// maxThreads is the most threads we'll search with.
+const maxThreads = 256
+
+// maxMultiPV is the most lines we'll search for in multi PV mode.
+const maxMultiPV = 256
+
+// maxContempt is the most centipawns either way we'll score a draw at.
+const maxContempt = 100
+
+// defaultBookDepth is the last full move we'll play from the book by default.
+const defaultBookDepth = 20
+
+// Values for the "Book Moves" option.
+const (
+	bookMovesWeighted = "Weighted"
+	bookMovesBest     = "Best"
+)
+
+func (a *adapter) Options() []uci.Option {
+	return []uci.Option{
+		{
+			Name:    optionHash,
+			Type:    uci.OptionTypeSpin,
+			Default: strconv.Itoa(engine.DefaultTranspositionTableMegabytes),
+			Min:     1,
+			Max:     maxHashMegabytes,
+		},
+		{
+			Name: optionClearHash,
+			Type: uci.OptionTypeButton,
+		},
+		{
+			Name:    optionThreads,
+			Type:    uci.OptionTypeSpin,
+			Default: "1",
+			Min:     1,
+			Max:     maxThreads,
+		},
+		{
+			Name:    optionChess960,
+			Type:    uci.OptionTypeCheck,
+			Default: "false",
+		},
+		{
+			Name:    optionOwnBook,
+			Type:    uci.OptionTypeCheck,
+			Default: "false",
+		},
+		{
+			// a Polyglot .bin book
+			Name: optionBookFile,
+			Type: uci.OptionTypeString,
+		},
+		{
+			Name:    optionBookDepth,
+			Type:    uci.OptionTypeSpin,
+			Default: strconv.Itoa(defaultBookDepth),
+			Min:     1,
+			Max:     100,
+		},
+		{
+			Name:    optionBookMoves,
+			Type:    uci.OptionTypeCombo,
+			Default: bookMovesWeighted,
+			Vars:    []string{bookMovesWeighted, bookMovesBest},
+		},
+		{
+			Name:    optionPonder,
+			Type:    uci.OptionTypeCheck,
+			Default: "false",
+		},
+		{
+			Name:    optionMultiPV,
+			Type:    uci.OptionTypeSpin,
+			Default: "1",
+			Min:     1,
+			Max:     maxMultiPV,
+		},
+		{
+			// how much worse than even, in centipawns, we score a draw
+			Name:    optionContempt,
+			Type:    uci.OptionTypeSpin,
+			Default: "0",
+			Min:     -maxContempt,
+			Max:     maxContempt,
+		},
+	}
+}
+
+func (a *adapter) SetOption(name string, value uci.OptionValue) error {
+	a.logger.Printf("set option %s: %+v", name, value)
+
+	switch name {
+	case optionHash:
+		a.tt = engine.NewTranspositionTable(int(value.Spin))
+		if a.game != nil {
+			a.game.SetTranspositionTable(a.tt)
+		}
+	case optionClearHash:
+		a.tt.Clear()
+	case optionThreads:
+		a.threads = int(value.Spin)
+		if a.game != nil {
+			a.game.SetThreads(a.threads)
+		}
+	case optionChess960:
+		// Chess960 positions are always understood, this only changes how
+		// castling is written
+		a.chess960 = value.Check
+	case optionOwnBook:
+		a.ownBook = value.Check
+	case optionBookFile:
+		return a.loadBook(value.String)
+	case optionBookDepth:
+		a.bookDepth = int(value.Spin)
+	case optionBookMoves:
+		a.bookBest = value.String == bookMovesBest
+	case optionPonder:
+		a.ponderEnabled = value.Check
+	case optionMultiPV:
+		a.multiPV = int(value.Spin)
+		if a.game != nil {
+			a.game.SetMultiPV(a.multiPV)
+		}
+	case optionContempt:
+		a.contempt = int(value.Spin)
+		if a.game != nil {
+			a.game.SetContempt(a.contempt)
+		}
+	default:
+		return fmt.Errorf("unsupported option: %s", name)
+	}
+	return nil
+}
+
+// loadBook reads the Polyglot book at path, or unloads the book if path is
+// empty.
+func (a *adapter) loadBook(path string) error {
+	if path == "" || path == "<empty>" {
+		a.book = nil
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	book, err := polyglot.Read(f)
+	if err != nil {
+		return fmt.Errorf("error reading book %s: %w", path, err)
+	}
+	a.logger.Printf("loaded book %s with %d entries", path, book.Len())
+	a.book = book
+	return nil
+}
+
+// bookMove returns a move from the book for the current position, and false if
+// the book isn't enabled or has no move for the position. The book isn't used
+// if the search is restricted to searchmoves.
+func (a *adapter) bookMove(searchmoves []chess.FromToPromoter) (engine.Move, bool) {
+	if !a.ownBook || a.book == nil || a.game.FullMoves() > a.bookDepth || len(searchmoves) > 0 {
+		return 0, false
+	}
+	m, ok, err := a.book.Move(a.game.Board, a.rnd, a.bookBest)
+	if err != nil {
+		a.logger.Printf("error probing book: %v", err)
+		return 0, false
+	}
+	if ok {
+		a.logger.Printf("book move %s", m.SAN())
+	}
+	return m, ok
+}
+
+// restrictSearch restricts the next search to searchmoves at the root, or lifts
+// the restriction if searchmoves is empty. Moves that don't make sense in the
+// current position are ignored.
+func (a *adapter) restrictSearch(searchmoves []chess.FromToPromoter) {
+	var moves []engine.Move
+	for _, sm := range searchmoves {
+		m, err := a.game.HydrateMove(sm)
+		if err != nil {
+			a.logger.Printf("ignoring search move %s: %v", uci.ToUCIN(sm), err)
+			continue
+		}
+		moves = append(moves, m)
+	}
+	a.game.SetSearchMoves(moves)
+}
+
 func (a *adapter) NewGame() error {
 	a.logger.Println("initialised new game")
+	a.tt.Clear()
 	a.game = engine.NewGame(nil)
+	a.game.SetTranspositionTable(a.tt)
+	a.game.SetMultiPV(a.multiPV)
+	a.game.SetThreads(a.threads)
+	a.game.SetContempt(a.contempt)
 	return nil
 }
 
@@ -88,63 +332,233 @@ func makeMoves(game *engine.Game, moves []chess.FromToPromoter) error {
 	return nil
 }
 
-func (a *adapter) GoDepth(plies uint8, stopch <-chan struct{}, responsech chan<- uci.Response) (chess.FromToPromoter, error) {
+func (a *adapter) GoDepth(plies uint8, searchmoves []chess.FromToPromoter, stopch <-chan struct{}, responsech chan<- uci.Response) (chess.FromToPromoter, error) {
 	a.logger.Println("go depth")
 
 	if a.game == nil {
 		return nil, errNoGame
 	}
 
+	a.restrictSearch(searchmoves)
+	if m, ok := a.bookMove(searchmoves); ok {
+		a.ponder = 0
+		return a.uciMove(m), nil
+	}
+
 	statusch := make(chan engine.SearchStatus, 100)
-	go forward(statusch, responsech)
+	done := a.forward(statusch, responsech)
 
-	depth := 2 * plies // convert from full moves to half moves
-	m, _ := a.game.BestMoveToDepth(depth, stopch, statusch)
-	return m, nil
+	depth := 2 * int(plies) // convert from full moves to half moves
+	if depth > math.MaxUint8 {
+		depth = math.MaxUint8
+	}
+	m, _ := a.game.BestMoveToDepth(uint8(depth), stopch, statusch)
+	close(statusch)
+	<-done
+	return a.searched(m), nil
 }
 
-func (a *adapter) GoNodes(nodes uint64, stopch <-chan struct{}, responsech chan<- uci.Response) (chess.FromToPromoter, error) {
+func (a *adapter) GoNodes(nodes uint64, searchmoves []chess.FromToPromoter, stopch <-chan struct{}, responsech chan<- uci.Response) (chess.FromToPromoter, error) {
 	a.logger.Println("go nodes")
 
 	if a.game == nil {
 		return nil, errNoGame
 	}
 
-	return nil, errors.New("GoNodes not implemented")
+	a.restrictSearch(searchmoves)
+	if m, ok := a.bookMove(searchmoves); ok {
+		a.ponder = 0
+		return a.uciMove(m), nil
+	}
+
+	statusch := make(chan engine.SearchStatus, 100)
+	done := a.forward(statusch, responsech)
+
+	m, _ := a.game.BestMoveToNodes(nodes, stopch, statusch)
+	<-done
+	return a.searched(m), nil
 }
 
-func (a *adapter) GoInfinite(stopch <-chan struct{}, responsech chan<- uci.Response) (chess.FromToPromoter, error) {
+func (a *adapter) GoInfinite(searchmoves []chess.FromToPromoter, stopch <-chan struct{}, responsech chan<- uci.Response) (chess.FromToPromoter, error) {
 	a.logger.Println("go infinite")
 
 	if a.game == nil {
 		return nil, errNoGame
 	}
 
+	a.restrictSearch(searchmoves)
+
 	statusch := make(chan engine.SearchStatus, 100)
-	go forward(statusch, responsech)
+	done := a.forward(statusch, responsech)
 
 	m, _ := a.game.BestMoveInfinite(stopch, statusch)
-	return m, nil
+	<-done
+	return a.searched(m), nil
 }
 
-func (a *adapter) GoTime(tc uci.TimeControl, stopch <-chan struct{}, responsech chan<- uci.Response) (chess.FromToPromoter, error) {
+func (a *adapter) GoTime(tc uci.TimeControl, searchmoves []chess.FromToPromoter, stopch <-chan struct{}, responsech chan<- uci.Response) (chess.FromToPromoter, error) {
 	a.logger.Println("go time")
 
 	if a.game == nil {
 		return nil, errNoGame
 	}
 
+	a.restrictSearch(searchmoves)
+	if m, ok := a.bookMove(searchmoves); ok {
+		a.ponder = 0
+		return a.uciMove(m), nil
+	}
+
+	statusch := make(chan engine.SearchStatus, 100)
+	done := a.forward(statusch, responsech)
+
+	m, _ := a.game.BestMoveToTime(tc.WhiteTime, tc.BlackTime, tc.WhiteIncrement, tc.BlackIncrement, tc.MovesToGo, stopch, statusch)
+	<-done
+	return a.searched(m), nil
+}
+
+func (a *adapter) GoMoveTime(movetime time.Duration, searchmoves []chess.FromToPromoter, stopch <-chan struct{}, responsech chan<- uci.Response) (chess.FromToPromoter, error) {
+	a.logger.Println("go movetime")
+
+	if a.game == nil {
+		return nil, errNoGame
+	}
+
+	a.restrictSearch(searchmoves)
+	if m, ok := a.bookMove(searchmoves); ok {
+		a.ponder = 0
+		return a.uciMove(m), nil
+	}
+
 	statusch := make(chan engine.SearchStatus, 100)
-	go forward(statusch, responsech)
+	done := a.forward(statusch, responsech)
 
-	m, _ := a.game.BestMoveToTime(tc.WhiteTime, tc.BlackTime, tc.WhiteIncrement, tc.BlackIncrement, stopch, statusch)
-	return m, nil
+	m, _ := a.game.BestMoveToMoveTime(movetime, stopch, statusch)
+	<-done
+	return a.searched(m), nil
+}
+
+func (a *adapter) GoMate(moves uint8, movetime time.Duration, tc uci.TimeControl, searchmoves []chess.FromToPromoter, stopch <-chan struct{}, responsech chan<- uci.Response) (chess.FromToPromoter, error) {
+	a.logger.Println("go mate")
+
+	if a.game == nil {
+		return nil, errNoGame
+	}
+
+	a.restrictSearch(searchmoves)
+
+	if movetime == 0 && tc != (uci.TimeControl{}) {
+		// we're playing to a clock, so don't spend any longer looking for the
+		// mate than we'd spend on any other move
+		_, movetime = a.game.TimeBudget(tc.WhiteTime, tc.BlackTime, tc.WhiteIncrement, tc.BlackIncrement, tc.MovesToGo)
+	}
+
+	statusch := make(chan engine.SearchStatus, 100)
+	done := a.forward(statusch, responsech)
+
+	m, _ := a.game.BestMoveToMate(moves, movetime, stopch, statusch)
+	<-done
+	return a.searched(m), nil
+}
+
+func (a *adapter) GoPonder(movetime time.Duration, tc uci.TimeControl, ponderhitch <-chan struct{}, searchmoves []chess.FromToPromoter, stopch <-chan struct{}, responsech chan<- uci.Response) (chess.FromToPromoter, error) {
+	a.logger.Println("go ponder")
+
+	if a.game == nil {
+		return nil, errNoGame
+	}
+
+	a.restrictSearch(searchmoves)
+
+	// the budget applies from the ponderhit, when our clock starts running;
+	// with neither a move time nor a clock we ponder until told to stop
+	var soft, hard time.Duration
+	switch {
+	case movetime > 0:
+		hard = movetime
+	case tc != (uci.TimeControl{}):
+		soft, hard = a.game.TimeBudget(tc.WhiteTime, tc.BlackTime, tc.WhiteIncrement, tc.BlackIncrement, tc.MovesToGo)
+	}
+
+	statusch := make(chan engine.SearchStatus, 100)
+	done := a.forward(statusch, responsech)
+
+	m, _ := a.game.BestMovePondering(soft, hard, ponderhitch, stopch, statusch)
+	<-done
+	return a.searched(m), nil
+}
+
+func (a *adapter) PonderMove() chess.FromToPromoter {
+	if !a.ponderEnabled || a.ponder == 0 {
+		return nil
+	}
+	return a.uciMove(a.ponder)
+}
+
+// searched records the reply we expect to m, the best move from a search that
+// just finished, and returns m as it should be written in UCI.
+func (a *adapter) searched(m engine.Move) chess.FromToPromoter {
+	a.ponder = 0
+	if pv := a.game.PrincipalVariation(); len(pv) >= 2 && pv[0] == m {
+		a.ponder = pv[1]
+	}
+	return a.uciMove(m)
 }
 
 // forward takes messages off statusch, converts them to uci responses and sends
-// them off on responsech.
-func forward(statusch <-chan engine.SearchStatus, responsech chan<- uci.Response) {
-	for info := range statusch {
-		responsech <- uci.ResponseSearchInformation{Depth: info.Depth}
+// them off on responsech. The returned channel is closed once statusch has been
+// closed and every message on it has been forwarded, so that callers can wait
+// to send the best move until after all of the search information.
+func (a *adapter) forward(statusch <-chan engine.SearchStatus, responsech chan<- uci.Response) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for status := range statusch {
+			responsech <- a.searchInformation(status)
+		}
+	}()
+	return done
+}
+
+// searchInformation converts the search status into a uci response.
+func (a *adapter) searchInformation(status engine.SearchStatus) uci.ResponseSearchInformation {
+	info := uci.ResponseSearchInformation{Depth: status.Depth}
+
+	if status.CurrentMove != 0 {
+		info.CurrentMove = a.uciMove(status.CurrentMove)
+		info.CurrentMoveNumber = status.CurrentMoveNumber
+		return info
+	}
+
+	info.SelectiveDepth = status.SelectiveDepth
+	info.MultiPV = status.MultiPV
+	info.Score = &uci.Score{Centipawns: int(status.Score), Mate: status.MateIn}
+	info.Nodes = status.Nodes
+	info.NodesPerSecond = status.NodesPerSecond
+	info.Time = status.Time
+	info.HashFull = status.HashFull
+	info.PrincipalVariation = make([]chess.FromToPromoter, len(status.PrincipalVariation))
+	for i, m := range status.PrincipalVariation {
+		info.PrincipalVariation[i] = a.uciMove(m)
 	}
+	return info
 }
+
+// uciMove returns m as it should be written in UCI. If UCI_Chess960 is set then
+// castling is written as the king capturing its own rook (e.g. "e1h1"), else
+// as the king moving two squares (e.g. "e1g1").
+func (a *adapter) uciMove(m engine.Move) chess.FromToPromoter {
+	if a.chess960 && (m.IsKingsideCastling() || m.IsQueensideCastling()) {
+		return kingTakesRook{from: m.From(), to: a.game.CastlingRook(m)}
+	}
+	return m
+}
+
+// kingTakesRook is castling written as the king capturing its own rook.
+type kingTakesRook struct {
+	from, to uint8
+}
+
+func (m kingTakesRook) From() uint8                { return m.from }
+func (m kingTakesRook) To() uint8                  { return m.to }
+func (m kingTakesRook) PromoteTo() chess.PromoteTo { return chess.PromoteToNone }
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/GeorgeBills/chess"
+	"github.com/GeorgeBills/chess/engine"
+	"github.com/GeorgeBills/chess/polyglot"
+	"github.com/GeorgeBills/chess/uci"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeBook writes a Polyglot book with the given entries, which must be sorted
+// by key, to a temporary file and returns its name.
+func writeBook(t *testing.T, entries ...polyglot.Entry) string {
+	f, err := ioutil.TempFile("", "book*.bin")
+	require.NoError(t, err)
+	defer f.Close()
+	for _, e := range entries {
+		require.NoError(t, binary.Write(f, binary.BigEndian, e))
+	}
+	return f.Name()
+}
+
+func TestBookMove(t *testing.T) {
+	key, err := polyglot.Key(engine.NewBoard())
+	if errors.Is(err, polyglot.ErrNoRandom64) {
+		t.Skip(err)
+	}
+	require.NoError(t, err)
+
+	// 1. e4 is the only move in the book
+	name := writeBook(t, polyglot.Entry{Key: key, Move: uint16(engine.E2)<<6 | uint16(engine.E4), Weight: 1})
+	defer os.Remove(name)
+
+	a := newAdapter(ioutil.Discard)
+	require.NoError(t, a.SetOption(optionOwnBook, uci.OptionValue{Check: true}))
+	require.NoError(t, a.SetOption(optionBookFile, uci.OptionValue{String: name}))
+	require.NoError(t, a.NewGame())
+	require.NoError(t, a.SetStartingPosition(nil))
+
+	m, ok := a.bookMove(nil)
+	require.True(t, ok)
+	assert.Equal(t, "e2e4", m.SAN())
+
+	_, ok = a.bookMove([]chess.FromToPromoter{m})
+	assert.False(t, ok, "should search rather than use the book with searchmoves")
+
+	require.NoError(t, a.SetStartingPosition([]chess.FromToPromoter{m}))
+	_, ok = a.bookMove(nil)
+	assert.False(t, ok, "should have no move for a position that isn't in the book")
+}
@@ -58,6 +58,15 @@ type Board struct {
 	// meta records meta information about the board, specifically castling
 	// rights and whether any pawn is vulnerable to en passant.
 	meta byte
+
+	// castling records the files of the rooks for each of the castling rights,
+	// indexed by castleWhiteKingside etc. In standard chess these are always
+	// the H and A files, but in Chess960 the rooks may start on any file.
+	castling [4]uint8
+
+	// hash is the Zobrist hash for the board, maintained incrementally by
+	// MakeMove() and UnmakeMove().
+	hash uint64
 }
 
 const (
@@ -70,21 +79,36 @@ const (
 	maskEnPassantFile        uint8 = 0b00000111 // the last 3 bits of meta indicate the file (zero indexed) for a valid en passant
 )
 
+// Indexes into Board.castling. The mask for each castling right in the board
+// meta is maskWhiteCastleKingside >> index.
+const (
+	castleWhiteKingside = iota
+	castleWhiteQueenside
+	castleBlackKingside
+	castleBlackQueenside
+)
+
+// castlingStandard holds the files of the castling rooks in standard chess.
+var castlingStandard = [4]uint8{fileH, fileA, fileH, fileA}
+
 // NewBoard returns a board in the initial state.
 func NewBoard() *Board {
-	return &Board{
-		white:   maskRank1 | maskRank2,
-		black:   maskRank7 | maskRank8,
-		pawns:   maskRank2 | maskRank7,
-		knights: 1<<B1 | 1<<G1 | 1<<B8 | 1<<G8,
-		bishops: 1<<C1 | 1<<F1 | 1<<C8 | 1<<F8,
-		rooks:   1<<A1 | 1<<H1 | 1<<A8 | 1<<H8,
-		queens:  1<<D1 | 1<<D8,
-		kings:   1<<E1 | 1<<E8,
-		half:    0,
-		total:   0,
-		meta:    maskWhiteCastleKingside | maskWhiteCastleQueenside | maskBlackCastleKingside | maskBlackCastleQueenside,
+	b := &Board{
+		white:    maskRank1 | maskRank2,
+		black:    maskRank7 | maskRank8,
+		pawns:    maskRank2 | maskRank7,
+		knights:  1<<B1 | 1<<G1 | 1<<B8 | 1<<G8,
+		bishops:  1<<C1 | 1<<F1 | 1<<C8 | 1<<F8,
+		rooks:    1<<A1 | 1<<H1 | 1<<A8 | 1<<H8,
+		queens:   1<<D1 | 1<<D8,
+		kings:    1<<E1 | 1<<E8,
+		half:     0,
+		total:    0,
+		meta:     maskWhiteCastleKingside | maskWhiteCastleQueenside | maskBlackCastleKingside | maskBlackCastleQueenside,
+		castling: castlingStandard,
 	}
+	b.hash = b.ComputeHash()
+	return b
 }
 
 // isWhiteAt returns true if there is a piece at index i and it is white.
@@ -193,6 +217,54 @@ func (b Board) CanBlackCastleKingside() bool { return b.meta&maskBlackCastleKing
 // CanBlackCastleQueenside returns true if black can castle queenside.
 func (b Board) CanBlackCastleQueenside() bool { return b.meta&maskBlackCastleQueenside != 0 }
 
+// castlingRook returns the starting square of the rook for the castling right
+// at index i into Board.castling.
+func (b *Board) castlingRook(i int) uint8 {
+	if i >= castleBlackKingside {
+		return chess.SquareIndex(rank8, b.castling[i])
+	}
+	return chess.SquareIndex(rank1, b.castling[i])
+}
+
+// castlingIndex returns the index into Board.castling for castling move m.
+func castlingIndex(m Move) int {
+	i := castleWhiteKingside
+	if m.From() >= A8 {
+		i = castleBlackKingside
+	}
+	if m.IsQueensideCastling() {
+		i++
+	}
+	return i
+}
+
+// CastlingRook returns the starting square of the rook that moves with the
+// castling move m. This is needed to write castling in Chess960 as the king
+// capturing its own rook, which is the convention the UCI_Chess960 option
+// expects.
+func (b *Board) CastlingRook(m Move) uint8 {
+	return b.castlingRook(castlingIndex(m))
+}
+
+// IsChess960 returns true if the castling rights of the board can't be
+// expressed in standard chess, i.e. a king that may castle isn't on the E file
+// or a rook that may castle isn't on the A or H file.
+func (b *Board) IsChess960() bool {
+	for i := castleWhiteKingside; i <= castleBlackQueenside; i++ {
+		if b.meta&(maskWhiteCastleKingside>>i) == 0 {
+			continue
+		}
+		king := b.kings & b.white
+		if i >= castleBlackKingside {
+			king = b.kings & b.black
+		}
+		if b.castling[i] != castlingStandard[i] || chess.FileIndex(uint8(bits.TrailingZeros64(king))) != fileE {
+			return true
+		}
+	}
+	return false
+}
+
 // HalfMoves returns the number of half moves (moves by one player) since the
 // last pawn moved or piece was captured. This is used for determining if a draw
 // can be claimed by the fifty move rule.
@@ -225,6 +297,8 @@ func (b Board) ColourFlipped() Board {
 	b.white, b.black = b.black, b.white // swap colours
 
 	b.meta ^= maskCastling // swap castling
+	b.castling[castleWhiteKingside], b.castling[castleBlackKingside] = b.castling[castleBlackKingside], b.castling[castleWhiteKingside]
+	b.castling[castleWhiteQueenside], b.castling[castleBlackQueenside] = b.castling[castleBlackQueenside], b.castling[castleWhiteQueenside]
 
 	var i uint8
 	for i = 0; i < 32; i++ {
@@ -237,6 +311,7 @@ func (b Board) ColourFlipped() Board {
 		b.setPieceAt(i, pj)
 		b.setPieceAt(j, pi)
 	}
+	b.hash = b.ComputeHash()
 	return b
 }
 
@@ -317,17 +392,28 @@ func (b Board) Validate() error {
 	if b.pawns&maskRank8 != 0 {
 		return errors.New("pawns on rank 8")
 	}
-	if b.CanBlackCastleKingside() && (b.PieceAt(E8) != PieceBlackKing || b.PieceAt(H8) != PieceBlackRook) {
-		return errors.New("invalid black castling: kingside implies king at E8 and rook at H8")
-	}
-	if b.CanBlackCastleQueenside() && (b.PieceAt(E8) != PieceBlackKing || b.PieceAt(A8) != PieceBlackRook) {
-		return errors.New("invalid black castling: queenside implies king at E8 and rook at A8")
-	}
-	if b.CanWhiteCastleKingside() && (b.PieceAt(E1) != PieceWhiteKing || b.PieceAt(H1) != PieceWhiteRook) {
-		return errors.New("invalid white castling: kingside implies king at E1 and rook at H1")
-	}
-	if b.CanWhiteCastleQueenside() && (b.PieceAt(E1) != PieceWhiteKing || b.PieceAt(A1) != PieceWhiteRook) {
-		return errors.New("invalid white castling: queenside implies king at E1 and rook at A1")
+	for i := castleWhiteKingside; i <= castleBlackQueenside; i++ {
+		if b.meta&(maskWhiteCastleKingside>>i) == 0 {
+			continue
+		}
+		colour, side, king, rook := "white", "kingside", b.kings&b.white, PieceWhiteRook
+		if i >= castleBlackKingside {
+			colour, king, rook = "black", b.kings&b.black, PieceBlackRook
+		}
+		if i == castleWhiteQueenside || i == castleBlackQueenside {
+			side = "queenside"
+		}
+		rookSq := b.castlingRook(i)
+		kingSq := uint8(bits.TrailingZeros64(king))
+		if b.PieceAt(rookSq) != rook {
+			return fmt.Errorf("invalid %s castling: %s implies rook at %s", colour, side, strings.ToUpper(chess.SquareIndexToAlgebraicNotation(rookSq)))
+		}
+		if chess.RankIndex(kingSq) != chess.RankIndex(rookSq) {
+			return fmt.Errorf("invalid %s castling: %s implies king on rank %d", colour, side, chess.RankIndex(rookSq)+1)
+		}
+		if (side == "kingside") != (chess.FileIndex(kingSq) < chess.FileIndex(rookSq)) {
+			return fmt.Errorf("invalid %s castling: %s implies rook on the %s of the king", colour, side, side)
+		}
 	}
 	return nil
 }
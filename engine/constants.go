@@ -133,3 +133,8 @@ const (
 	maskFileG uint64 = 1<<G1 | 1<<G2 | 1<<G3 | 1<<G4 | 1<<G5 | 1<<G6 | 1<<G7 | 1<<G8
 	maskFileH uint64 = 1<<H1 | 1<<H2 | 1<<H3 | 1<<H4 | 1<<H5 | 1<<H6 | 1<<H7 | 1<<H8
 )
+
+const (
+	maskDarkSquares  uint64 = 0xAA55AA55AA55AA55
+	maskLightSquares uint64 = ^maskDarkSquares
+)
@@ -0,0 +1,80 @@
+package engine
+
+import "math/bits"
+
+// https://www.chessprogramming.org/Repetitions
+// https://www.chessprogramming.org/Fifty-move_Rule
+// https://www.chessprogramming.org/Draw_Evaluation
+
+// fiftyMoveRule is the number of half moves without a pawn move or capture
+// after which the game is drawn.
+const fiftyMoveRule = 100
+
+// Repetitions returns the number of times the current position has occurred in
+// the game, including this occurrence. Positions are compared by their hash,
+// which includes the en passant file after any pawn double push, even if no
+// pawn can actually capture en passant.
+func (g *Game) Repetitions() int {
+	n := 1
+	for i := len(g.history) - 4; g.canRepeat(i); i -= 2 {
+		if g.history[i].previousHash == g.hash {
+			n++
+		}
+	}
+	return n
+}
+
+// isRepetition returns true if the current position has occurred at least once
+// before in the game. The search treats a single repetition as a draw: if
+// repeating was the best either side could do the first time, it will be again.
+func (g *Game) isRepetition() bool {
+	for i := len(g.history) - 4; g.canRepeat(i); i -= 2 {
+		if g.history[i].previousHash == g.hash {
+			return true
+		}
+	}
+	return false
+}
+
+// canRepeat returns true if the position before the move at index i in the
+// history could be a repetition of the current position. No position before the
+// last pawn move or capture can ever repeat. Callers check only positions with
+// the same side to move, starting from four half moves ago, since that's the
+// soonest a position can repeat.
+func (g *Game) canRepeat(i int) bool {
+	return i >= 0 && i >= len(g.history)-int(g.half)
+}
+
+// IsFiftyMoveRule returns true if fifty moves have been made by each player
+// without a pawn move or capture.
+func (b *Board) IsFiftyMoveRule() bool {
+	return b.half >= fiftyMoveRule
+}
+
+// IsInsufficientMaterial returns true if neither player has enough material to
+// checkmate the other by any sequence of legal moves: king against king, king
+// and a single minor piece against king, or kings and bishops with every bishop
+// on squares of the same colour.
+func (b *Board) IsInsufficientMaterial() bool {
+	if b.pawns|b.rooks|b.queens != 0 {
+		return false
+	}
+	if bits.OnesCount64(b.knights|b.bishops) <= 1 {
+		return true
+	}
+	return b.knights == 0 && (b.bishops&maskDarkSquares == 0 || b.bishops&maskLightSquares == 0)
+}
+
+// IsDraw returns true if the game is drawn by threefold repetition, the fifty
+// move rule or insufficient material. It doesn't consider stalemate, and the
+// fifty move rule doesn't apply if the player to move has been checkmated.
+func (g *Game) IsDraw() bool {
+	return g.Repetitions() >= threefold || g.IsFiftyMoveRule() && !g.isCheckmate() || g.IsInsufficientMaterial()
+}
+
+// isCheckmate returns true if the player to move is in check with no legal
+// moves.
+func (g *Game) isCheckmate() bool {
+	moves, isCheck := g.GenerateLegalMoves(nil)
+	return len(moves) == 0 && isCheck
+}
@@ -0,0 +1,122 @@
+package engine_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/GeorgeBills/chess/engine"
+	"github.com/GeorgeBills/chess/uci"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newGameWithMoves returns a new game from fen with the moves (in long
+// algebraic notation) already made.
+func newGameWithMoves(t *testing.T, fen string, moves ...string) *engine.Game {
+	b, err := engine.NewBoardFromFEN(strings.NewReader(fen))
+	require.NoError(t, err)
+	g := engine.NewGame(b)
+	for _, ucin := range moves {
+		parsed, err := uci.ParseUCIN(ucin)
+		require.NoError(t, err)
+		move, err := g.HydrateMove(parsed)
+		require.NoError(t, err)
+		g.MakeMove(move)
+	}
+	return g
+}
+
+func TestRepetitions(t *testing.T) {
+	tests := []struct {
+		name     string
+		moves    []string
+		expected int
+	}{
+		{"no moves", nil, 1},
+		{"no repetition", []string{"g1f3", "g8f6", "f3g1"}, 1},
+		{"twofold", []string{"g1f3", "g8f6", "f3g1", "f6g8"}, 2},
+		{"threefold", []string{"g1f3", "g8f6", "f3g1", "f6g8", "g1f3", "g8f6", "f3g1", "f6g8"}, 3},
+		{"threefold with other moves between", []string{"g1f3", "g8f6", "f3g1", "f6g8", "b1c3", "b8c6", "c3b1", "c6b8"}, 3},
+		{"broken by a pawn move", []string{"g1f3", "g8f6", "f3g1", "f6g8", "e2e3", "e7e6", "g1f3", "g8f6", "f3g1", "f6g8"}, 2},
+		{"castling rights differ", []string{"e2e4", "e7e5", "e1e2", "e8e7", "e2e1", "e7e8"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newGameWithMoves(t, engine.InitialBoardFEN, tt.moves...)
+			assert.Equal(t, tt.expected, g.Repetitions())
+			assert.Equal(t, tt.expected >= 3, g.IsDraw())
+		})
+	}
+}
+
+func TestFiftyMoveRule(t *testing.T) {
+	const fen = "k7/8/8/8/8/8/P7/4Q2K w - - 99 80"
+
+	g := newGameWithMoves(t, fen)
+	assert.False(t, g.IsFiftyMoveRule())
+
+	g = newGameWithMoves(t, fen, "e1e2")
+	assert.Equal(t, 100, g.HalfMoves())
+	assert.True(t, g.IsFiftyMoveRule())
+	assert.True(t, g.IsDraw())
+
+	g.UnmakeMove()
+	assert.Equal(t, 99, g.HalfMoves())
+	assert.False(t, g.IsFiftyMoveRule())
+
+	g = newGameWithMoves(t, fen, "a2a3")
+	assert.Equal(t, 0, g.HalfMoves(), "pawn moves reset the half move clock")
+
+	g = newGameWithMoves(t, "k7/8/8/8/8/8/p7/4Q2K w - - 99 80", "e1a5", "a8b7", "a5a2")
+	assert.Equal(t, 0, g.HalfMoves(), "captures reset the half move clock")
+
+	g = newGameWithMoves(t, "k7/8/1K6/8/8/8/P7/4Q3 w - - 99 80", "e1e8")
+	assert.Equal(t, 100, g.HalfMoves())
+	assert.True(t, g.IsFiftyMoveRule())
+	assert.False(t, g.IsDraw(), "checkmate takes precedence over the fifty move rule")
+	assert.Equal(t, engine.TerminationCheckmate, g.Outcome().Termination)
+}
+
+func TestIsInsufficientMaterial(t *testing.T) {
+	tests := []struct {
+		name     string
+		fen      string
+		expected bool
+	}{
+		{"initial board", engine.InitialBoardFEN, false},
+		{"king vs king", "8/8/4k3/8/8/3K4/8/8 w - - 0 1", true},
+		{"king and knight vs king", "8/8/4k3/8/8/3K4/8/6N1 w - - 0 1", true},
+		{"king and bishop vs king", "8/8/4k3/8/8/3K4/8/5b2 w - - 0 1", true},
+		{"bishops on same coloured squares", "8/8/4k3/8/8/3K4/4B3/5b2 w - - 0 1", true},
+		{"bishops on opposite coloured squares", "8/8/4k3/8/8/3K4/5B2/5b2 w - - 0 1", false},
+		{"two knights", "8/8/4k3/8/8/3K4/8/5NN1 w - - 0 1", false},
+		{"knight vs bishop", "8/8/4k3/8/8/3K4/8/5Nb1 w - - 0 1", false},
+		{"king and pawn vs king", "8/8/4k3/8/8/3K4/4P3/8 w - - 0 1", false},
+		{"king and rook vs king", "8/8/4k3/8/8/3K4/8/7R w - - 0 1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := engine.NewBoardFromFEN(strings.NewReader(tt.fen))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, b.IsInsufficientMaterial())
+		})
+	}
+}
+
+func TestBestMoveToDepthRepetition(t *testing.T) {
+	const fen = "k7/8/8/8/8/8/8/4Q2K w - - 0 1"
+
+	t.Run("losing side repeats", func(t *testing.T) {
+		g := newGameWithMoves(t, fen, "e1e2", "a8b8", "e2e1", "b8a8", "e1e2")
+		move, score := g.BestMoveToDepth(2, nil, nil)
+		assert.Equal(t, "a8b8", move.SAN())
+		assert.EqualValues(t, 0, score)
+	})
+
+	t.Run("winning side avoids repetition", func(t *testing.T) {
+		g := newGameWithMoves(t, fen, "e1e2", "a8b8", "e2e1", "b8a8")
+		move, score := g.BestMoveToDepth(2, nil, nil)
+		assert.NotEqual(t, "e1e2", move.SAN())
+		assert.Greater(t, score, int16(0))
+	})
+}
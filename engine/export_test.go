@@ -0,0 +1,6 @@
+package engine
+
+// SetQuiescence turns quiescence search on or off for subsequent searches.
+func (g *Game) SetQuiescence(on bool) {
+	g.noQuiescence = !on
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"math/bits"
 	"strconv"
 	"strings"
 
@@ -99,17 +100,20 @@ func (b *Board) WriteFEN(w io.Writer) error {
 	if b.meta&(maskWhiteCastleKingside|maskWhiteCastleQueenside|maskBlackCastleKingside|maskBlackCastleQueenside) == 0 {
 		sb.WriteRune('-')
 	} else {
-		if b.CanWhiteCastleKingside() {
-			sb.WriteRune('K')
-		}
-		if b.CanWhiteCastleQueenside() {
-			sb.WriteRune('Q')
-		}
-		if b.CanBlackCastleKingside() {
-			sb.WriteRune('k')
-		}
-		if b.CanBlackCastleQueenside() {
-			sb.WriteRune('q')
+		// Castling is written as X-FEN: KQkq if the castling rook is the
+		// outermost rook on that side of the king (which is always true in
+		// standard chess), else the file of the rook.
+		for i, ch := range "KQkq" {
+			if b.meta&(maskWhiteCastleKingside>>i) == 0 {
+				continue
+			}
+			if b.castling[i] != b.outermostRook(i) {
+				ch = rune('A' + b.castling[i])
+				if i >= castleBlackKingside {
+					ch = rune('a' + b.castling[i])
+				}
+			}
+			sb.WriteRune(ch)
 		}
 	}
 
@@ -139,7 +143,7 @@ func (b *Board) WriteFEN(w io.Writer) error {
 // Forsyth–Edwards Notation. Only 8×8 boards are supported. Only basic
 // validation of resulting board state is performed.
 func NewBoardFromFEN(fen io.Reader) (*Board, error) {
-	b := &Board{}
+	b := &Board{castling: castlingStandard}
 	r := bufio.NewReaderSize(fen, maxFEN)
 
 	// TODO: can we use Peek here to simplify "seen" checks?
@@ -300,28 +304,41 @@ READ_CASTLING:
 		if err != nil {
 			return nil, unexpectingEOF(err)
 		}
-		switch ch {
-		case 'K':
+		switch {
+		case ch == 'K':
 			b.meta |= maskWhiteCastleKingside
-		case 'Q':
+			b.castling[castleWhiteKingside] = b.outermostRook(castleWhiteKingside)
+		case ch == 'Q':
 			b.meta |= maskWhiteCastleQueenside
-		case 'k':
+			b.castling[castleWhiteQueenside] = b.outermostRook(castleWhiteQueenside)
+		case ch == 'k':
 			b.meta |= maskBlackCastleKingside
-		case 'q':
+			b.castling[castleBlackKingside] = b.outermostRook(castleBlackKingside)
+		case ch == 'q':
 			b.meta |= maskBlackCastleQueenside
-		case '-':
+			b.castling[castleBlackQueenside] = b.outermostRook(castleBlackQueenside)
+		case 'A' <= ch && ch <= 'H':
+			// Shredder-FEN (or X-FEN) gives the file of the castling rook
+			i := b.castlingIndexForFile(White, uint8(ch-'A'))
+			b.meta |= maskWhiteCastleKingside >> i
+			b.castling[i] = uint8(ch - 'A')
+		case 'a' <= ch && ch <= 'h':
+			i := b.castlingIndexForFile(Black, uint8(ch-'a'))
+			b.meta |= maskWhiteCastleKingside >> i
+			b.castling[i] = uint8(ch - 'a')
+		case ch == '-':
 			// '-' indicates that castling is unavailable
 			// if present it must be the one and only rune
 			if b.meta&(maskWhiteCastleKingside|maskWhiteCastleQueenside|maskBlackCastleKingside|maskBlackCastleQueenside) != 0 {
 				return nil, errors.New("castling '-' must be solitary if present")
 			}
 			break READ_CASTLING
-		case ' ':
+		case ch == ' ':
 			// TODO: should require at least one rune read here for robustness (use Peek?)
 			r.UnreadRune()
 			break READ_CASTLING
 		default:
-			return nil, fmt.Errorf("unexpected '%c', expecting [KQkq]", ch)
+			return nil, fmt.Errorf("unexpected '%c', expecting [KQkqA-Ha-h]", ch)
 		}
 	}
 
@@ -399,5 +416,55 @@ READ_CASTLING:
 		return nil, fmt.Errorf("invalid board: %w", err)
 	}
 
+	b.hash = b.ComputeHash()
+
 	return b, nil
 }
+
+// outermostRook returns the file of the rook furthest from the king on the
+// side of the castling right at index i into Board.castling, which is the rook
+// that K, Q, k and q refer to in X-FEN. If there is no such rook then the file
+// is that of standard chess, and Validate() will reject the board.
+func (b *Board) outermostRook(i int) uint8 {
+	colour, rank := b.white, uint8(rank1)
+	if i >= castleBlackKingside {
+		colour, rank = b.black, rank8
+	}
+	king := b.kings & colour
+	rooks := b.rooks & colour
+	if king == 0 {
+		return castlingStandard[i]
+	}
+	kingSq := uint8(bits.TrailingZeros64(king))
+	if chess.RankIndex(kingSq) != rank {
+		return castlingStandard[i]
+	}
+	if i == castleWhiteKingside || i == castleBlackKingside {
+		for sq := chess.SquareIndex(rank, fileH); sq > kingSq; sq-- {
+			if rooks&(1<<sq) != 0 {
+				return chess.FileIndex(sq)
+			}
+		}
+	} else {
+		for sq := chess.SquareIndex(rank, fileA); sq < kingSq; sq++ {
+			if rooks&(1<<sq) != 0 {
+				return chess.FileIndex(sq)
+			}
+		}
+	}
+	return castlingStandard[i]
+}
+
+// castlingIndexForFile returns the index into Board.castling for castling by
+// colour with the rook on file: kingside if the rook is on the H side of the
+// king, else queenside.
+func (b *Board) castlingIndexForFile(colour Colour, file uint8) int {
+	i, king := castleWhiteKingside, b.kings&b.white
+	if colour == Black {
+		i, king = castleBlackKingside, b.kings&b.black
+	}
+	if king != 0 && file < chess.FileIndex(uint8(bits.TrailingZeros64(king))) {
+		i++
+	}
+	return i
+}
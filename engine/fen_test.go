@@ -107,6 +107,8 @@ func TestRoundTripFEN(t *testing.T) {
 		"5N2/8/1b1p2r1/8/2PP4/2kp1p1K/1p2n2B/q3r1N1 b - - 0 1",
 		// FEN with En Passant indicated
 		"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1",
+		// X-FEN with a Chess960 castling rook that isn't the outermost rook
+		"r3k1rr/8/8/8/8/8/8/R3K1RR w GQgq - 0 1",
 	}
 	for i, tt := range fen {
 		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
@@ -118,6 +120,34 @@ func TestRoundTripFEN(t *testing.T) {
 	}
 }
 
+func TestChess960FEN(t *testing.T) {
+	tests := []struct {
+		name      string
+		fen       string
+		expected  string
+		chess960  bool
+		king      uint8
+		kingside  uint8
+		queenside uint8
+	}{
+		{"standard", engine.InitialBoardFEN, engine.InitialBoardFEN, false, engine.E1, engine.H1, engine.A1},
+		{"standard as Shredder-FEN", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w HAha - 0 1", engine.InitialBoardFEN, false, engine.E1, engine.H1, engine.A1},
+		{"Shredder-FEN", "bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w HFhf - 2 9", "bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w KQkq - 2 9", true, engine.G1, engine.H1, engine.F1},
+		{"X-FEN", "bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w KQkq - 2 9", "bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w KQkq - 2 9", true, engine.G1, engine.H1, engine.F1},
+		{"inner rook", "r3k1rr/8/8/8/8/8/8/R3K1RR w GAga - 0 1", "r3k1rr/8/8/8/8/8/8/R3K1RR w GQgq - 0 1", true, engine.E1, engine.G1, engine.A1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := engine.NewBoardFromFEN(strings.NewReader(tt.fen))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, b.FEN())
+			assert.Equal(t, tt.chess960, b.IsChess960())
+			assert.Equal(t, tt.kingside, b.CastlingRook(engine.NewKingsideCastle(tt.king)))
+			assert.Equal(t, tt.queenside, b.CastlingRook(engine.NewQueensideCastle(tt.king)))
+		})
+	}
+}
+
 func TestWriteFENError(t *testing.T) {
 	err := engine.NewBoard().WriteFEN(&errorWriter{})
 	assert.EqualError(t, err, "error writing")
@@ -110,29 +110,14 @@ func init() {
 	}
 }
 
-// "One may not castle out of, through, or into check".
-//
-// The castle threat mask constants track the squares for the kings starting
-// position, the square the king will move through, and the kings final
-// position. If one of these squares is under threat then the relevant castling
-// is not legal.
-const (
-	maskWhiteKingsideCastleThreat  uint64 = 1<<E1 | 1<<F1 | 1<<G1
-	maskWhiteQueensideCastleThreat uint64 = 1<<C1 | 1<<D1 | 1<<E1
-	maskBlackKingsideCastleThreat  uint64 = 1<<E8 | 1<<F8 | 1<<G8
-	maskBlackQueensideCastleThreat uint64 = 1<<C8 | 1<<D8 | 1<<E8
-)
-
-// In order to castle there must be no spaces in between the king and the rook.
-// The castle block mask constants track the squares in between the king and the
-// rook. If one of these squares is occupied then the relevant castling is not
-// legal.
-const (
-	maskWhiteKingsideCastleBlocked  uint64 = 1<<F1 | 1<<G1
-	maskWhiteQueensideCastleBlocked uint64 = 1<<B1 | 1<<C1 | 1<<D1
-	maskBlackKingsideCastleBlocked  uint64 = 1<<F8 | 1<<G8
-	maskBlackQueensideCastleBlocked uint64 = 1<<B8 | 1<<C8 | 1<<D8
-)
+// squaresBetween returns a mask of the squares from a to b inclusive, which
+// must be on the same rank.
+func squaresBetween(a, b uint8) uint64 {
+	if a > b {
+		a, b = b, a
+	}
+	return (1<<(b+1) - 1) &^ (1<<a - 1)
+}
 
 // TODO: https://www.chessprogramming.org/Move_List to reduce memory alloc churn
 
@@ -331,33 +316,53 @@ func (b *Board) GenerateLegalMoves(moves []Move) ([]Move, bool) {
 
 	// Check for castling.
 	//
-	// We completely rely on the castling flags set in the board state for
-	// these, and don't check if there is a king and rook pair in the required
-	// squares. Validate() will save us from loading in bad FEN with castling
-	// rights set incorrectly, and given that we just need to make sure we
-	// always unset the castling right flags when we need to in MakeMove().
-	switch tomove {
-	case White:
-		if b.CanWhiteCastleKingside() &&
-			threatened&maskWhiteKingsideCastleThreat == 0 &&
-			occupied&maskWhiteKingsideCastleBlocked == 0 {
-			moves = append(moves, WhiteKingsideCastle)
-		}
-		if b.CanWhiteCastleQueenside() &&
-			threatened&maskWhiteQueensideCastleThreat == 0 &&
-			occupied&maskWhiteQueensideCastleBlocked == 0 {
-			moves = append(moves, WhiteQueensideCastle)
-		}
-	case Black:
-		if b.CanBlackCastleKingside() &&
-			threatened&maskBlackKingsideCastleThreat == 0 &&
-			occupied&maskBlackKingsideCastleBlocked == 0 {
-			moves = append(moves, BlackKingsideCastle)
+	// We completely rely on the castling flags and rook files set in the board
+	// state for these, and don't check if there is a king and rook pair in the
+	// required squares. Validate() will save us from loading in bad FEN with
+	// castling rights set incorrectly, and given that we just need to make sure
+	// we always unset the castling right flags when we need to in MakeMove().
+	//
+	// In Chess960 the king and rook may start on any file, but finish on the
+	// same squares as in standard chess. "One may not castle out of, through,
+	// or into check", so none of the squares from the king's starting square
+	// to its final square may be threatened. All of the squares that the king
+	// and rook move over or on to must be empty, other than those occupied by
+	// the castling king and rook themselves.
+	if b.meta&maskCastling != 0 {
+		kingSq := uint8(bits.TrailingZeros64(king))
+		kingside, queenside := castleWhiteKingside, castleWhiteQueenside
+		if tomove == Black {
+			kingside, queenside = castleBlackKingside, castleBlackQueenside
 		}
-		if b.CanBlackCastleQueenside() &&
-			threatened&maskBlackQueensideCastleThreat == 0 &&
-			occupied&maskBlackQueensideCastleBlocked == 0 {
-			moves = append(moves, BlackQueensideCastle)
+		for _, castle := range [...]struct {
+			i int
+			m Move
+		}{
+			{kingside, NewKingsideCastle(kingSq)},
+			{queenside, NewQueensideCastle(kingSq)},
+		} {
+			if b.meta&(maskWhiteCastleKingside>>castle.i) == 0 {
+				continue
+			}
+			kingTo := castle.m.To()
+			rookFrom, rookTo := b.castlingRook(castle.i), castlingRookTo(castle.m)
+			var rookbit uint64 = 1 << rookFrom
+			if threatened&squaresBetween(kingSq, kingTo) != 0 ||
+				occupied&^king&^rookbit&(squaresBetween(kingSq, kingTo)|squaresBetween(rookFrom, rookTo)) != 0 {
+				continue
+			}
+			// The castling rook may have been blocking an opposing rook or
+			// queen on the back rank from the king's final square, e.g. with
+			// the rook on B1 and an opposing queen on A1. This can only happen
+			// in Chess960.
+			after := occupied&^king&^rookbit | 1<<rookTo
+			west, east := movesWest[kingTo]&after, movesEast[kingTo]&after
+			_, westbit := popMSB(&west)
+			_, eastbit := popLSB(&east)
+			if (westbit|eastbit)&(b.rooks|b.queens)&opposing != 0 {
+				continue
+			}
+			moves = append(moves, castle.m)
 		}
 	}
 
@@ -482,10 +487,10 @@ func (b *Board) GenerateLegalMoves(moves []Move) ([]Move, bool) {
 			if breakEnPassant(epCaptureSq, maskRank5) {
 				break EN_PASSANT // would put king in check
 			}
-			if from := epSquare - 7; pawns&^pinnedAny&^maskFileA&(1<<from) != 0 { // sw
+			if from := epSquare - 7; pawns&^pinnedExceptDiagonalNWSE&^maskFileA&(1<<from) != 0 { // sw
 				moves = append(moves, NewEnPassant(from, from+7)) // ne
 			}
-			if from := epSquare - 9; pawns&^pinnedAny&^maskFileH&(1<<from) != 0 { // se
+			if from := epSquare - 9; pawns&^pinnedExceptDiagonalSWNE&^maskFileH&(1<<from) != 0 { // se
 				moves = append(moves, NewEnPassant(from, from+9)) // nw
 			}
 		case Black:
@@ -499,10 +504,10 @@ func (b *Board) GenerateLegalMoves(moves []Move) ([]Move, bool) {
 			if breakEnPassant(epCaptureSq, maskRank4) {
 				break EN_PASSANT // would put king in check
 			}
-			if from := epSquare + 7; pawns&^pinnedAny&^maskFileH&(1<<from) != 0 {
+			if from := epSquare + 7; pawns&^pinnedExceptDiagonalNWSE&^maskFileH&(1<<from) != 0 {
 				moves = append(moves, NewEnPassant(from, from-7)) // se
 			}
-			if from := epSquare + 9; pawns&^pinnedAny&^maskFileA&(1<<from) != 0 {
+			if from := epSquare + 9; pawns&^pinnedExceptDiagonalSWNE&^maskFileA&(1<<from) != 0 {
 				moves = append(moves, NewEnPassant(from, from-9)) // sw
 			}
 		}
@@ -3,8 +3,10 @@ package engine
 import (
 	"errors"
 	"fmt"
+	"math"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/GeorgeBills/chess"
 )
@@ -39,7 +41,21 @@ func NewEnPassant(from, to uint8) Move {
 	return NewMove(from, to) | moveIsEnPassant
 }
 
-// Castling moves are represented with constants.
+// NewKingsideCastle returns a new move which represents kingside castling by
+// the king on square from. The king always finishes on the G file, and the
+// rook on the F file.
+func NewKingsideCastle(from uint8) Move {
+	return NewMove(from, chess.SquareIndex(chess.RankIndex(from), fileG)) | moveIsKingsideCastle
+}
+
+// NewQueensideCastle returns a new move which represents queenside castling by
+// the king on square from. The king always finishes on the C file, and the
+// rook on the D file.
+func NewQueensideCastle(from uint8) Move {
+	return NewMove(from, chess.SquareIndex(chess.RankIndex(from), fileC)) | moveIsQueensideCastle
+}
+
+// Castling moves in standard chess are represented with constants.
 const (
 	BlackKingsideCastle  = Move(uint16(E8)<<6|uint16(G8)) | moveIsKingsideCastle
 	BlackQueensideCastle = Move(uint16(E8)<<6|uint16(C8)) | moveIsQueensideCastle
@@ -90,9 +106,9 @@ func NewBishopPromotion(from, to uint8, capture bool) Move {
 // Somewhat Algebraic Notation always includes both source square and target
 // square (similarly to Pure Coordinate Notation), and never includes the piece
 // type. Piece type can be unambiguously determined from the source square and
-// the current state of the board.
+// the current state of the board. Game.SAN returns the move in Standard
+// Algebraic Notation.
 func (m Move) SAN() string {
-	// TODO: convert to proper SAN e.g. "Nf3", "e4"; will need the board
 	if m == 0 {
 		return "-" // TODO: 0000 in UCI
 	}
@@ -203,12 +219,114 @@ func (m Move) PromoteTo() chess.PromoteTo {
 type Game struct {
 	*Board  // TODO: Board (no ref), to embed mem and avoid lots of pointer lookups?
 	history []moveCapture
+
+	// tt is consulted and filled by the search. It may be nil, in which case
+	// the search runs without a transposition table.
+	tt *TranspositionTable
+
+	// stopped latches to true once the current search has been told to stop.
+	stopped bool
+
+	// nodes counts the nodes visited by the current search.
+	nodes uint64
+
+	// maxNodes is the number of nodes the current search may visit, or 0 if
+	// there's no limit.
+	maxNodes uint64
+
+	// killers holds, for each ply, the two most recent quiet moves that caused
+	// a beta cutoff. They're tried early when searching sibling positions.
+	killers [maxPly][2]Move
+
+	// historyTable scores quiet moves by how often (and how deep) they've
+	// caused beta cutoffs, indexed by side to move, from and to square.
+	historyTable [2][64][64]int32
+
+	// pv holds the principal variation found at each ply, as a "triangular"
+	// table: pv[ply][ply:pvLength[ply]] is the principal variation from ply.
+	pv       [maxPly][maxPly]Move
+	pvLength [maxPly]uint8
+
+	// selDepth is the deepest ply reached by the current search.
+	selDepth uint8
+
+	// searchStart is when the current search started.
+	searchStart time.Time
+
+	// searchMoves, if not empty, restricts the search to these moves at the
+	// root.
+	searchMoves []Move
+
+	// multiPV is how many of the best moves at the root the search finds, each
+	// with its own score and principal variation. 0 is the same as 1.
+	multiPV int
+
+	// threads is how many threads the search uses. 0 is the same as 1.
+	threads int
+
+	// helpers are the helper threads of the current search, if it's multi
+	// threaded.
+	helpers *helpers
+
+	// contempt is how much worse than even, in centipawns, the side to move
+	// at the root of the search scores a draw.
+	contempt int16
+
+	// noQuiescence turns off quiescence search, so that positions at depth 0
+	// are evaluated as they stand. It's only set by tests, to compare the
+	// search with a plain minimax.
+	noQuiescence bool
 }
 
 func (g *Game) SetBoard(b *Board) {
 	g.Board = b
 }
 
+// History returns the moves that have been made in the game, oldest first.
+func (g *Game) History() []Move {
+	moves := make([]Move, len(g.history))
+	for i, mc := range g.history {
+		moves[i] = mc.Move
+	}
+	return moves
+}
+
+// SetTranspositionTable sets the transposition table used by the search.
+func (g *Game) SetTranspositionTable(tt *TranspositionTable) {
+	g.tt = tt
+}
+
+// SetSearchMoves restricts subsequent searches to the given moves at the root.
+// Moves that aren't legal in the position being searched are ignored, and if
+// none of them are legal (or moves is empty) every legal move is searched.
+func (g *Game) SetSearchMoves(moves []Move) {
+	g.searchMoves = moves
+}
+
+// SetContempt sets how much worse than even, in centipawns, subsequent searches
+// score a draw for the side to move. A positive contempt makes the search avoid
+// draws against a weaker opponent; a negative one makes it seek them out
+// against a stronger one.
+func (g *Game) SetContempt(centipawns int) {
+	g.contempt = int16(centipawns)
+}
+
+// SetMultiPV sets how many of the best moves at the root subsequent searches
+// find, each with its own score and principal variation, which are reported in
+// the search status as separate lines. The best move is still returned.
+func (g *Game) SetMultiPV(n int) {
+	g.multiPV = n
+}
+
+// SetThreads sets how many threads subsequent searches use. The first thread
+// searches as normal, and any others help by searching the same position in
+// parallel, sharing the transposition table ("Lazy SMP"). Only single threaded
+// searches are deterministic. Searches with more than one thread should be
+// given a transposition table, since that's all the threads share.
+func (g *Game) SetThreads(n int) {
+	g.threads = n
+}
+
 // moveCapture represents a chess move (including meta information such as
 // whether the move is a capture, en passant, castling, etc) along with the
 // information required to reverse the move.
@@ -219,6 +337,8 @@ type moveCapture struct {
 	Move
 	capture      Piece
 	previousMeta byte
+	previousHalf uint8
+	previousHash uint64
 }
 
 func NewGame(b *Board) *Game { // TODO: don't take board here, always use SetBoard?
@@ -270,27 +390,27 @@ func (b *Board) HydrateMove(m chess.FromToPromoter) (Move, error) {
 		}
 	}
 
+	if b.isKingAt(fromSq) && b.isRookAt(toSq) && b.isWhiteAt(fromSq) == b.isWhiteAt(toSq) {
+		// the king "capturing" its own rook is castling, as written in
+		// Chess960 (e.g. "e1h1" is white kingside castling)
+		if chess.FileIndex(toSq) > chess.FileIndex(fromSq) {
+			return NewKingsideCastle(fromSq), nil
+		}
+		return NewQueensideCastle(fromSq), nil
+	}
+
 	if isCapture {
 		return NewCapture(fromSq, toSq), nil
 	}
 
-	if b.isKingAt(fromSq) {
-		if fromSq == E1 {
-			if toSq == G1 { // "e1g1" is white kingside castling
-				return WhiteKingsideCastle, nil
-			}
-			if toSq == C1 { // "e1c1" is white queenside castling
-				return WhiteQueensideCastle, nil
-			}
-		}
-
-		if fromSq == E8 {
-			if toSq == G8 { // "e8g8" is black kingside castling
-				return BlackKingsideCastle, nil
-			}
-			if toSq == C8 { // "e8c8" is black queensdie castling
-				return BlackQueensideCastle, nil
-			}
+	if b.isKingAt(fromSq) && chess.RankIndex(fromSq) == chess.RankIndex(toSq) {
+		// the king moving two files to the G or C file is castling (e.g.
+		// "e1g1" is white kingside castling)
+		switch {
+		case toSq == fromSq+2 && chess.FileIndex(toSq) == fileG:
+			return NewKingsideCastle(fromSq), nil
+		case toSq == fromSq-2 && chess.FileIndex(toSq) == fileC:
+			return NewQueensideCastle(fromSq), nil
 		}
 	}
 
@@ -303,37 +423,43 @@ func (g *Game) MakeMove(move Move) {
 	from, to := move.From(), move.To()
 	var frombit, tobit uint64 = 1 << from, 1 << to
 
+	moving := g.PieceAt(from)
+
 	mc := moveCapture{
 		Move:         move,
-		capture:      g.PieceAt(to),
+		capture:      PieceNone,
 		previousMeta: g.meta,
+		previousHalf: g.half,
+		previousHash: g.hash,
+	}
+	if !move.IsKingsideCastling() && !move.IsQueensideCastling() {
+		// in Chess960 the king may castle on to its own rook's square
+		mc.capture = g.PieceAt(to)
 	}
 	g.history = append(g.history, mc)
 
+	// reset the half move clock on any pawn move or capture
+	switch {
+	case moving&PiecePawn != 0, mc.capture != PieceNone:
+		g.half = 0
+	case g.half < math.MaxUint8:
+		g.half++
+	}
+
+	// incrementally update the hash: remove the moving piece from its origin
+	// square and any captured piece from the destination square, flip the
+	// side to move, and remove the previous castling and en passant keys.
+	// castling and en passant keys for the new meta are added at the end.
+	g.hash ^= zobristPiece(moving, from)
+	if mc.capture != PieceNone {
+		g.hash ^= zobristPiece(mc.capture, to)
+	}
+	g.hash ^= zobristKeys[zobristOffsetToMove]
+	g.hash ^= zobristMeta(g.meta)
+
 	// remove castling rights if we need to
-	switch from {
-	case A1: // white queenside rook starting square
-		g.meta &^= maskWhiteCastleQueenside
-	case E1: // white king starting square
-		g.meta &^= maskWhiteCastleKingside | maskWhiteCastleQueenside
-	case H1: // white kingside rook starting square
-		g.meta &^= maskWhiteCastleKingside
-	case A8: // black queenside rook starting square
-		g.meta &^= maskBlackCastleQueenside
-	case E8: // black king starting square
-		g.meta &^= maskBlackCastleKingside | maskBlackCastleQueenside
-	case H8: // black kingside rook starting square
-		g.meta &^= maskBlackCastleKingside
-	}
-	switch to {
-	case A1: // white queenside rook starting square
-		g.meta &^= maskWhiteCastleQueenside
-	case H1: // white kingside rook starting square
-		g.meta &^= maskWhiteCastleKingside
-	case A8: // black queenside rook starting square
-		g.meta &^= maskBlackCastleQueenside
-	case H8: // black kingside rook starting square
-		g.meta &^= maskBlackCastleKingside
+	if g.meta&maskCastling != 0 {
+		g.meta &^= g.castlingRightsLost(moving, from, to)
 	}
 
 	// clear en passant if any was present
@@ -345,44 +471,32 @@ func (g *Game) MakeMove(move Move) {
 	case move.IsPawnDoublePush():
 		g.meta |= maskCanEnPassant
 		g.meta |= chess.FileIndex(from)
-	case move.IsKingsideCastling():
-		switch tomove {
-		case White:
-			const togglebits uint64 = 1<<F1 | 1<<H1
-			g.white ^= togglebits
-			g.rooks ^= togglebits
-			g.meta &^= maskWhiteCastleKingside | maskWhiteCastleQueenside
-		case Black:
-			const togglebits uint64 = 1<<F8 | 1<<H8
-			g.black ^= togglebits
-			g.rooks ^= togglebits
-			g.meta &^= maskBlackCastleKingside | maskBlackCastleQueenside
-		}
-	case move.IsQueensideCastling():
-		switch tomove {
-		case White:
-			const togglebits uint64 = 1<<A1 | 1<<D1
-			g.white ^= togglebits
-			g.rooks ^= togglebits
-			g.meta &^= maskWhiteCastleKingside | maskWhiteCastleQueenside
-		case Black:
-			const togglebits uint64 = 1<<A8 | 1<<D8
-			g.black ^= togglebits
-			g.rooks ^= togglebits
-			g.meta &^= maskBlackCastleKingside | maskBlackCastleQueenside
-		}
+	case move.IsKingsideCastling(), move.IsQueensideCastling():
+		// castling is handled separately, since in Chess960 the king or rook
+		// may finish on the other's starting square
+		rook := g.CastlingRook(move)
+		g.castle(tomove, from, rook, to, castlingRookTo(move))
+		g.hash ^= zobristPiece(moving, to)
+		g.hash ^= zobristPiece(moving^PieceKing|PieceRook, rook) ^ zobristPiece(moving^PieceKing|PieceRook, castlingRookTo(move))
+		g.hash ^= zobristMeta(g.meta)
+		g.total++
+		return
 	case move.IsPromotion():
 		// swap our pawn out for the piece it's promoting to before it moves
 		g.pawns &^= frombit
 		switch {
 		case move&moveIsQueenPromotion == moveIsQueenPromotion:
 			g.queens |= frombit
+			moving = moving&(PieceWhite|PieceBlack) | PieceQueen
 		case move&moveIsKnightPromotion == moveIsKnightPromotion:
 			g.knights |= frombit
+			moving = moving&(PieceWhite|PieceBlack) | PieceKnight
 		case move&moveIsRookPromotion == moveIsRookPromotion:
 			g.rooks |= frombit
+			moving = moving&(PieceWhite|PieceBlack) | PieceRook
 		case move&moveIsBishopPromotion == moveIsBishopPromotion:
 			g.bishops |= frombit
+			moving = moving&(PieceWhite|PieceBlack) | PieceBishop
 		default:
 			panic(fmt.Errorf("promotion to unknown piece: %b", move))
 		}
@@ -392,13 +506,20 @@ func (g *Game) MakeMove(move Move) {
 			epCaptureSq := to - 8
 			g.black &^= 1 << epCaptureSq
 			g.pawns &^= 1 << epCaptureSq
+			g.hash ^= zobristPiece(PieceBlackPawn, epCaptureSq)
 		case Black:
 			epCaptureSq := to + 8
 			g.white &^= 1 << epCaptureSq
 			g.pawns &^= 1 << epCaptureSq
+			g.hash ^= zobristPiece(PieceWhitePawn, epCaptureSq)
 		}
 	}
 
+	// add the moved (or promoted) piece at its destination square, and the
+	// keys for the new castling rights and en passant file
+	g.hash ^= zobristPiece(moving, to)
+	g.hash ^= zobristMeta(g.meta)
+
 	// remove any opposing piece on our destination square
 	g.pawns &^= tobit
 	g.knights &^= tobit
@@ -446,6 +567,49 @@ func (g *Game) MakeMove(move Move) {
 	g.total++
 }
 
+// castlingRightsLost returns the castling rights that are lost by moving piece
+// from one square to another: all of them for the side if the king moves, or
+// the right for a rook if it moves or is captured.
+func (b *Board) castlingRightsLost(moving Piece, from, to uint8) uint8 {
+	var lost uint8
+	switch moving {
+	case PieceWhiteKing:
+		lost = maskWhiteCastleKingside | maskWhiteCastleQueenside
+	case PieceBlackKing:
+		lost = maskBlackCastleKingside | maskBlackCastleQueenside
+	}
+	for i := castleWhiteKingside; i <= castleBlackQueenside; i++ {
+		if rook := b.castlingRook(i); rook == from || rook == to {
+			lost |= maskWhiteCastleKingside >> i
+		}
+	}
+	return lost
+}
+
+// castlingRookTo returns the square the rook finishes on for castling move m:
+// the F file for kingside castling or the D file for queenside castling.
+func castlingRookTo(m Move) uint8 {
+	if m.IsKingsideCastling() {
+		return m.To() - 1
+	}
+	return m.To() + 1
+}
+
+// castle moves the king and rook of colour from their starting squares to
+// their finishing squares. Both are removed before either is placed, since in
+// Chess960 either of them may finish on the other's starting square.
+func (b *Board) castle(colour Colour, kingFrom, rookFrom, kingTo, rookTo uint8) {
+	var frombits, tobits uint64 = 1<<kingFrom | 1<<rookFrom, 1<<kingTo | 1<<rookTo
+	switch colour {
+	case White:
+		b.white = b.white&^frombits | tobits
+	case Black:
+		b.black = b.black&^frombits | tobits
+	}
+	b.kings = b.kings&^(1<<kingFrom) | 1<<kingTo
+	b.rooks = b.rooks&^(1<<rookFrom) | 1<<rookTo
+}
+
 // UnmakeMove unapplies the most recent move on the board.
 func (g *Game) UnmakeMove() {
 	tomove := g.ToMove()
@@ -455,32 +619,20 @@ func (g *Game) UnmakeMove() {
 	from, to := move.To(), move.From() // flip from and to
 	var frombit, tobit uint64 = 1 << from, 1 << to
 
-	// restore previous meta
+	// restore previous meta, half move clock and hash
 	g.meta = move.previousMeta
+	g.half = move.previousHalf
+	g.hash = move.previousHash
 
 	switch {
-	case move.IsKingsideCastling():
-		switch tomove {
-		case Black:
-			const togglebits uint64 = 1<<F1 | 1<<H1
-			g.white ^= togglebits
-			g.rooks ^= togglebits
-		case White:
-			const togglebits uint64 = 1<<F8 | 1<<H8
-			g.black ^= togglebits
-			g.rooks ^= togglebits
-		}
-	case move.IsQueensideCastling():
-		switch tomove {
-		case Black:
-			const togglebits uint64 = 1<<A1 | 1<<D1
-			g.white ^= togglebits
-			g.rooks ^= togglebits
-		case White:
-			const togglebits uint64 = 1<<A8 | 1<<D8
-			g.black ^= togglebits
-			g.rooks ^= togglebits
+	case move.IsKingsideCastling(), move.IsQueensideCastling():
+		colour := White
+		if tomove == White {
+			colour = Black
 		}
+		g.castle(colour, from, castlingRookTo(move.Move), to, g.CastlingRook(move.Move))
+		g.total--
+		return
 	case move.IsPromotion():
 		// "unpromote" our promoted piece
 		g.pawns |= frombit
@@ -0,0 +1,144 @@
+package engine
+
+// https://www.chessprogramming.org/Move_Ordering
+// https://www.chessprogramming.org/MVV-LVA
+// https://www.chessprogramming.org/Killer_Heuristic
+// https://www.chessprogramming.org/History_Heuristic
+
+// maxMoves is an upper bound on the number of legal moves in any position.
+// https://www.chessprogramming.org/Chess_Position#Maximum_number_of_moves
+const maxMoves = 256
+
+// maxPly is the number of plies from the root for which we track killer moves.
+const maxPly = 128
+
+// Moves are ordered by descending score. Each kind of move is given a score in
+// its own band, so that e.g. any capture is always tried before any killer.
+const (
+	orderHashMove int32 = 1 << 30
+	orderCapture  int32 = 1 << 29
+	orderKiller   int32 = 1 << 28
+	maxHistory    int32 = orderKiller - 1
+)
+
+// ordinal returns the relative value of the piece at sq, from 1 for a pawn up
+// to 6 for a king, or 0 if the square is empty.
+func (b *Board) ordinal(sq uint8) int16 {
+	var bit uint64 = 1 << sq
+	switch {
+	case b.pawns&bit != 0:
+		return 1
+	case b.knights&bit != 0:
+		return 2
+	case b.bishops&bit != 0:
+		return 3
+	case b.rooks&bit != 0:
+		return 4
+	case b.queens&bit != 0:
+		return 5
+	case b.kings&bit != 0:
+		return 6
+	default:
+		return 0
+	}
+}
+
+// scoreMVVLVA scores a capture or promotion by "Most Valuable Victim, Least
+// Valuable Aggressor": capturing a queen with a pawn is most likely to be a good
+// move, and capturing a pawn with a queen least likely. Promoting to a queen is
+// scored as if capturing one.
+func (b *Board) scoreMVVLVA(m Move) int16 {
+	var victim int16
+	switch {
+	case m.IsEnPassant():
+		victim = 1
+	case m.IsCapture():
+		victim = b.ordinal(m.To())
+	}
+	if m&moveIsQueenPromotion == moveIsQueenPromotion {
+		victim += 5
+	}
+	return 8*victim - b.ordinal(m.From())
+}
+
+// sideIndex returns 0 if white is to move and 1 if black is to move.
+func (g *Game) sideIndex() int {
+	if g.ToMove() == White {
+		return 0
+	}
+	return 1
+}
+
+// orderMoves sorts moves in place so that those most likely to cause a beta
+// cutoff are searched first: the hash move (the best move from a previous
+// search of this position), then captures and queen promotions by MVV-LVA,
+// then killer moves for this ply, then every other move by its history score.
+func (g *Game) orderMoves(moves []Move, hashMove Move, ply uint8) {
+	var scores [maxMoves]int32
+	var killers [2]Move
+	if ply < maxPly {
+		killers = g.killers[ply]
+	}
+	side := g.sideIndex()
+	for i, m := range moves {
+		switch {
+		case m == hashMove:
+			scores[i] = orderHashMove
+		case m.IsCapture() || m&moveIsQueenPromotion == moveIsQueenPromotion:
+			scores[i] = orderCapture + int32(g.scoreMVVLVA(m))
+		case m == killers[0]:
+			scores[i] = orderKiller
+		case m == killers[1]:
+			scores[i] = orderKiller - 1
+		default:
+			scores[i] = g.historyTable[side][m.From()][m.To()]
+		}
+	}
+	sortMoves(moves, scores[:len(moves)])
+}
+
+// recordCutoff updates the killer moves and history table after m caused a
+// beta cutoff at the given ply with depth remaining. Captures and queen
+// promotions are already ordered early, so only quiet moves are recorded.
+func (g *Game) recordCutoff(m Move, ply, depth uint8) {
+	if m.IsCapture() || m&moveIsQueenPromotion == moveIsQueenPromotion {
+		return
+	}
+
+	if ply < maxPly && g.killers[ply][0] != m {
+		g.killers[ply][1] = g.killers[ply][0]
+		g.killers[ply][0] = m
+	}
+
+	h := &g.historyTable[g.sideIndex()][m.From()][m.To()]
+	*h += int32(depth) * int32(depth)
+	if *h > maxHistory {
+		g.ageHistory()
+	}
+}
+
+// ageHistory halves every score in the history table, so that the table favours
+// recent cutoffs and scores stay below maxHistory.
+func (g *Game) ageHistory() {
+	for side := range g.historyTable {
+		for from := range g.historyTable[side] {
+			for to := range g.historyTable[side][from] {
+				g.historyTable[side][from][to] /= 2
+			}
+		}
+	}
+}
+
+// sortMoves sorts moves in place by descending score, where scores[i] is the
+// score for moves[i]. Move lists are short, so a simple insertion sort is quick
+// and doesn't allocate. The sort is stable.
+func sortMoves(moves []Move, scores []int32) {
+	for i := 1; i < len(moves); i++ {
+		m, s := moves[i], scores[i]
+		j := i
+		for ; j > 0 && scores[j-1] < s; j-- {
+			moves[j], scores[j] = moves[j-1], scores[j-1]
+		}
+		moves[j], scores[j] = m, s
+	}
+}
@@ -0,0 +1,123 @@
+package engine
+
+// https://www.chessprogramming.org/Checkmate
+// https://www.chessprogramming.org/Stalemate
+// https://www.chessprogramming.org/Draw
+
+// Termination is the reason that a game has ended, or that a draw may be
+// claimed.
+type Termination uint8
+
+// Terminations defined by the FIDE Laws of Chess.
+const (
+	TerminationNone                 Termination = iota // the game isn't over
+	TerminationCheckmate                               // the player to move is checkmated
+	TerminationStalemate                               // the player to move has no legal moves
+	TerminationInsufficientMaterial                    // neither player can checkmate
+	TerminationFivefoldRepetition                      // the position has occurred five times
+	TerminationSeventyFiveMoveRule                     // 75 moves each without a pawn move or capture
+	TerminationThreefoldRepetition                     // the position has occurred three times
+	TerminationFiftyMoveRule                           // 50 moves each without a pawn move or capture
+)
+
+// Half move counts for the fifty and seventy-five move rules, and position
+// counts for threefold and fivefold repetition.
+const (
+	seventyFiveMoveRule = 150
+	threefold           = 3
+	fivefold            = 5
+)
+
+// String returns a human readable description of the termination.
+func (t Termination) String() string {
+	switch t {
+	case TerminationNone:
+		return "none"
+	case TerminationCheckmate:
+		return "checkmate"
+	case TerminationStalemate:
+		return "stalemate"
+	case TerminationInsufficientMaterial:
+		return "insufficient material"
+	case TerminationFivefoldRepetition:
+		return "fivefold repetition"
+	case TerminationSeventyFiveMoveRule:
+		return "seventy-five move rule"
+	case TerminationThreefoldRepetition:
+		return "threefold repetition"
+	case TerminationFiftyMoveRule:
+		return "fifty move rule"
+	default:
+		return "unknown"
+	}
+}
+
+// Outcome is the outcome of a game.
+type Outcome struct {
+	Termination Termination
+
+	// Winner is the colour of the winning player if the game was won, or 0 if
+	// the game is drawn or not yet over.
+	Winner Colour
+}
+
+// IsOver returns true if the game has ended.
+func (o Outcome) IsOver() bool { return o.Termination != TerminationNone }
+
+// IsDraw returns true if the game has ended in a draw.
+func (o Outcome) IsDraw() bool { return o.IsOver() && o.Winner == 0 }
+
+// Result returns the result of the game as written in PGN: "1-0" if white won,
+// "0-1" if black won, "1/2-1/2" for a draw or "*" if the game isn't over.
+func (o Outcome) Result() string {
+	switch {
+	case o.Winner == White:
+		return "1-0"
+	case o.Winner == Black:
+		return "0-1"
+	case o.IsOver():
+		return "1/2-1/2"
+	default:
+		return "*"
+	}
+}
+
+// Outcome returns the outcome of the game in the current position. Only
+// terminations that end the game automatically are reported: a draw by
+// threefold repetition or the fifty move rule must be claimed by a player (see
+// ClaimableDraw), and until then the game continues.
+func (g *Game) Outcome() Outcome {
+	moves, isCheck := g.GenerateLegalMoves(nil)
+	switch {
+	case len(moves) == 0 && isCheck:
+		winner := White
+		if g.ToMove() == White {
+			winner = Black
+		}
+		return Outcome{Termination: TerminationCheckmate, Winner: winner}
+	case len(moves) == 0:
+		return Outcome{Termination: TerminationStalemate}
+	case g.IsInsufficientMaterial():
+		return Outcome{Termination: TerminationInsufficientMaterial}
+	case g.Repetitions() >= fivefold:
+		return Outcome{Termination: TerminationFivefoldRepetition}
+	case g.half >= seventyFiveMoveRule:
+		return Outcome{Termination: TerminationSeventyFiveMoveRule}
+	default:
+		return Outcome{Termination: TerminationNone}
+	}
+}
+
+// ClaimableDraw returns TerminationThreefoldRepetition or
+// TerminationFiftyMoveRule if the player to move may claim a draw in the
+// current position, or TerminationNone if they can't.
+func (g *Game) ClaimableDraw() Termination {
+	switch {
+	case g.Repetitions() >= threefold:
+		return TerminationThreefoldRepetition
+	case g.IsFiftyMoveRule():
+		return TerminationFiftyMoveRule
+	default:
+		return TerminationNone
+	}
+}
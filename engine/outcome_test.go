@@ -0,0 +1,119 @@
+package engine_test
+
+import (
+	"testing"
+
+	"github.com/GeorgeBills/chess/engine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutcome(t *testing.T) {
+	knightShuffle := []string{"g1f3", "g8f6", "f3g1", "f6g8"}
+	repeat := func(n int) []string {
+		var moves []string
+		for i := 0; i < n; i++ {
+			moves = append(moves, knightShuffle...)
+		}
+		return moves
+	}
+
+	tests := []struct {
+		name      string
+		fen       string
+		moves     []string
+		expected  engine.Outcome
+		result    string
+		claimable engine.Termination
+	}{
+		{
+			"initial board",
+			engine.InitialBoardFEN,
+			nil,
+			engine.Outcome{Termination: engine.TerminationNone},
+			"*",
+			engine.TerminationNone,
+		},
+		{
+			"fool's mate",
+			engine.InitialBoardFEN,
+			[]string{"f2f3", "e7e5", "g2g4", "d8h4"},
+			engine.Outcome{Termination: engine.TerminationCheckmate, Winner: engine.Black},
+			"0-1",
+			engine.TerminationNone,
+		},
+		{
+			"back rank mate",
+			"5k2/4ppp1/8/8/8/8/8/R2bK3 w Q - 0 1",
+			[]string{"a1a8"},
+			engine.Outcome{Termination: engine.TerminationCheckmate, Winner: engine.White},
+			"1-0",
+			engine.TerminationNone,
+		},
+		{
+			"stalemate",
+			"7k/5Q2/6K1/8/8/8/8/8 b - - 0 1",
+			nil,
+			engine.Outcome{Termination: engine.TerminationStalemate},
+			"1/2-1/2",
+			engine.TerminationNone,
+		},
+		{
+			"insufficient material",
+			"8/8/4k3/8/8/3K4/8/6N1 w - - 0 1",
+			nil,
+			engine.Outcome{Termination: engine.TerminationInsufficientMaterial},
+			"1/2-1/2",
+			engine.TerminationNone,
+		},
+		{
+			"threefold repetition is claimable",
+			engine.InitialBoardFEN,
+			repeat(2),
+			engine.Outcome{Termination: engine.TerminationNone},
+			"*",
+			engine.TerminationThreefoldRepetition,
+		},
+		{
+			"fivefold repetition",
+			engine.InitialBoardFEN,
+			repeat(4),
+			engine.Outcome{Termination: engine.TerminationFivefoldRepetition},
+			"1/2-1/2",
+			engine.TerminationThreefoldRepetition,
+		},
+		{
+			"fifty move rule is claimable",
+			"k7/8/8/8/8/8/P7/4Q2K w - - 99 80",
+			[]string{"e1e2"},
+			engine.Outcome{Termination: engine.TerminationNone},
+			"*",
+			engine.TerminationFiftyMoveRule,
+		},
+		{
+			"seventy-five move rule",
+			"k7/8/8/8/8/8/P7/4Q2K w - - 149 80",
+			[]string{"e1e2"},
+			engine.Outcome{Termination: engine.TerminationSeventyFiveMoveRule},
+			"1/2-1/2",
+			engine.TerminationFiftyMoveRule,
+		},
+		{
+			"checkmate takes precedence over the seventy-five move rule",
+			"5k2/4ppp1/8/8/8/8/8/R2bK3 w - - 149 80",
+			[]string{"a1a8"},
+			engine.Outcome{Termination: engine.TerminationCheckmate, Winner: engine.White},
+			"1-0",
+			engine.TerminationFiftyMoveRule,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newGameWithMoves(t, tt.fen, tt.moves...)
+			outcome := g.Outcome()
+			assert.Equal(t, tt.expected, outcome)
+			assert.Equal(t, tt.result, outcome.Result())
+			assert.Equal(t, tt.expected.Termination != engine.TerminationNone, outcome.IsOver())
+			assert.Equal(t, tt.claimable, g.ClaimableDraw())
+		})
+	}
+}
@@ -91,6 +91,66 @@ func TestPerft(t *testing.T) {
 	}
 }
 
+func TestPerftChess960(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping TestPerftChess960() due to -short flag")
+	}
+
+	// https://www.chessprogramming.org/Chess960_Perft_Results
+	tests := []struct {
+		name     string
+		fen      string
+		depth    uint8
+		expected uint64
+	}{
+		{
+			"position 1",
+			"bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w HFhf - 2 9",
+			4,
+			326_672,
+		},
+		{
+			"position 2",
+			"2nnrbkr/p1qppppp/8/1ppb4/6PP/3PP3/PPP2P2/BQNNRBKR w HEhe - 1 9",
+			4,
+			667_366,
+		},
+		{
+			"position 3",
+			"b1q1rrkb/pppppppp/3nn3/8/P7/1PPP4/4PPPP/BQNNRKRB w GE - 1 9",
+			4,
+			273_318,
+		},
+		{
+			"position 4",
+			"qbbnnrkr/2pp2pp/p7/1p2pp2/8/P3PP2/1PPP1KPP/QBBNNR1R w hf - 0 9",
+			4,
+			382_958,
+		},
+		{
+			"position 5",
+			"1nbbnrkr/p1p1ppp1/3p4/1p3P1p/3Pq2P/8/PPP1P1P1/QNBBNRKR w HFhf - 0 9",
+			4,
+			1_171_749,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fen, depth, expected := tt.fen, tt.depth, tt.expected
+
+			t.Parallel()
+
+			b, err := engine.NewBoardFromFEN(strings.NewReader(fen))
+			require.NoError(t, err)
+			require.NotNil(t, b)
+			g := engine.NewGame(b)
+			n := perft(g, depth)
+
+			assert.Equal(t, expected, n)
+		})
+	}
+}
+
 func BenchmarkPerft(b *testing.B) {
 	if testing.Short() {
 		b.Skip("Skipping BenchmarkPerft() due to -short flag")
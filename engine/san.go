@@ -0,0 +1,271 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/GeorgeBills/chess"
+)
+
+// https://www.chessprogramming.org/Algebraic_Chess_Notation#Standard_Algebraic_Notation_.28SAN.29
+
+// Errors returned by ParseSAN, wrapped with details of the move.
+var (
+	ErrMalformedSAN = errors.New("malformed SAN")
+	ErrIllegalMove  = errors.New("illegal move")
+	ErrAmbiguousSAN = errors.New("ambiguous SAN")
+)
+
+// SAN returns the move m, which must be legal in the current position, in
+// Standard Algebraic Notation (e.g. "Nf3", "exd5", "O-O", "e8=Q+").
+//
+// Pieces other than pawns are identified by their letter. If more than one
+// piece of the same type could move to the target square then the source file,
+// rank or (if neither alone is enough) square is included to disambiguate. Pawn
+// captures are identified by the source file. Moves that give check are
+// suffixed with "+", and moves that give checkmate with "#".
+func (g *Game) SAN(m Move) string {
+	var san strings.Builder
+
+	switch {
+	case m.IsKingsideCastling():
+		san.WriteString("O-O")
+	case m.IsQueensideCastling():
+		san.WriteString("O-O-O")
+	default:
+		from, to := m.From(), m.To()
+		piece := g.PieceAt(from)
+		if piece&PiecePawn != 0 {
+			if m.IsCapture() {
+				san.WriteByte('a' + chess.FileIndex(from))
+			}
+		} else {
+			san.WriteByte(sanPieceLetter(piece))
+			san.WriteString(g.sanDisambiguation(m, piece))
+		}
+		if m.IsCapture() {
+			san.WriteByte('x')
+		}
+		san.WriteString(chess.SquareIndexToAlgebraicNotation(to))
+		if m.IsPromotion() {
+			san.WriteByte('=')
+			san.WriteByte(sanPromotionLetter(m))
+		}
+	}
+
+	g.MakeMove(m)
+	moves, isCheck := g.GenerateLegalMoves(nil)
+	g.UnmakeMove()
+	switch {
+	case isCheck && len(moves) == 0:
+		san.WriteByte('#')
+	case isCheck:
+		san.WriteByte('+')
+	}
+
+	return san.String()
+}
+
+// sanDisambiguation returns the source file, rank or square needed to tell m
+// apart from moves by other pieces of the same type to the same square, or an
+// empty string if no other such piece can move there.
+func (g *Game) sanDisambiguation(m Move, piece Piece) string {
+	from, to := m.From(), m.To()
+
+	var ambiguous, sameFile, sameRank bool
+	moves, _ := g.GenerateLegalMoves(nil)
+	for _, other := range moves {
+		if other.To() != to || other.From() == from || g.PieceAt(other.From()) != piece {
+			continue
+		}
+		ambiguous = true
+		sameFile = sameFile || chess.FileIndex(other.From()) == chess.FileIndex(from)
+		sameRank = sameRank || chess.RankIndex(other.From()) == chess.RankIndex(from)
+	}
+
+	square := chess.SquareIndexToAlgebraicNotation(from)
+	switch {
+	case !ambiguous:
+		return ""
+	case !sameFile:
+		return square[:1]
+	case !sameRank:
+		return square[1:]
+	default:
+		return square
+	}
+}
+
+// sanPieceLetter returns the letter identifying the type of piece p in SAN.
+func sanPieceLetter(p Piece) byte {
+	switch {
+	case p&PieceKnight != 0:
+		return 'N'
+	case p&PieceBishop != 0:
+		return 'B'
+	case p&PieceRook != 0:
+		return 'R'
+	case p&PieceQueen != 0:
+		return 'Q'
+	case p&PieceKing != 0:
+		return 'K'
+	default:
+		return 'P'
+	}
+}
+
+// sanPromotionLetter returns the letter identifying the piece that the
+// promotion m promotes to in SAN.
+func sanPromotionLetter(m Move) byte {
+	switch m.PromoteTo() {
+	case chess.PromoteToQueen:
+		return 'Q'
+	case chess.PromoteToKnight:
+		return 'N'
+	case chess.PromoteToRook:
+		return 'R'
+	default:
+		return 'B'
+	}
+}
+
+// ParseSAN parses san as a move in Standard Algebraic Notation, resolving it
+// against the legal moves in the current position.
+//
+// Parsing is lenient in what it accepts: check, checkmate and annotation
+// suffixes ("+", "#", "!", "?") are ignored, "x" may be omitted from captures,
+// "=" may be omitted from promotions, and castling may be written with zeros.
+// The error returned wraps ErrMalformedSAN if san can't be parsed,
+// ErrIllegalMove if no legal move matches, and ErrAmbiguousSAN if more than one
+// legal move matches.
+func (b *Board) ParseSAN(san string) (Move, error) {
+	s := strings.TrimRight(san, "+#!?")
+
+	switch s {
+	case "O-O", "0-0":
+		return b.findSAN(san, func(m Move) bool { return m.IsKingsideCastling() })
+	case "O-O-O", "0-0-0":
+		return b.findSAN(san, func(m Move) bool { return m.IsQueensideCastling() })
+	}
+
+	piece := PiecePawn
+	if len(s) > 0 {
+		if p := sanPiece(s[0]); p != PieceNone {
+			piece = p
+			s = s[1:]
+		}
+	}
+
+	var promoteTo chess.PromoteTo
+	if n := len(s); n > 0 {
+		if p := sanPromoteTo(s[n-1]); p != chess.PromoteToNone {
+			promoteTo = p
+			s = strings.TrimSuffix(s[:n-1], "=")
+		}
+	}
+
+	if len(s) < 2 {
+		return 0, fmt.Errorf("%w: %q", ErrMalformedSAN, san)
+	}
+	rank, file, err := chess.ParseAlgebraicNotationString(s[len(s)-2:])
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q: %v", ErrMalformedSAN, san, err)
+	}
+	to := chess.SquareIndex(rank, file)
+	s = s[:len(s)-2]
+
+	capture := strings.HasSuffix(s, "x")
+	s = strings.TrimSuffix(s, "x")
+
+	// anything remaining must disambiguate the source file, rank or both
+	fromFile, fromRank := -1, -1
+	if len(s) > 0 && s[0] >= 'a' && s[0] <= 'h' {
+		fromFile = int(s[0] - 'a')
+		s = s[1:]
+	}
+	if len(s) > 0 && s[0] >= '1' && s[0] <= '8' {
+		fromRank = int(s[0] - '1')
+		s = s[1:]
+	}
+	if len(s) > 0 {
+		return 0, fmt.Errorf("%w: %q: unexpected %q", ErrMalformedSAN, san, s)
+	}
+
+	return b.findSAN(san, func(m Move) bool {
+		from := m.From()
+		switch {
+		case m.To() != to,
+			b.PieceAt(from)&piece == 0,
+			m.IsKingsideCastling() || m.IsQueensideCastling(),
+			capture && !m.IsCapture(),
+			fromFile >= 0 && int(chess.FileIndex(from)) != fromFile,
+			fromRank >= 0 && int(chess.RankIndex(from)) != fromRank:
+			return false
+		case m.IsPromotion():
+			return m.PromoteTo() == promoteTo
+		default:
+			return promoteTo == chess.PromoteToNone
+		}
+	})
+}
+
+// findSAN returns the only legal move that matches, or an error if there are
+// no matches or more than one.
+func (b *Board) findSAN(san string, match func(Move) bool) (Move, error) {
+	var found []Move
+	moves, _ := b.GenerateLegalMoves(nil)
+	for _, m := range moves {
+		if match(m) {
+			found = append(found, m)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return 0, fmt.Errorf("%w: %q", ErrIllegalMove, san)
+	case 1:
+		return found[0], nil
+	default:
+		matches := make([]string, len(found))
+		for i, m := range found {
+			matches[i] = m.SAN()
+		}
+		return 0, fmt.Errorf("%w: %q matches %s", ErrAmbiguousSAN, san, strings.Join(matches, ", "))
+	}
+}
+
+// sanPiece returns the type of piece identified by the letter ch in SAN, or
+// PieceNone if ch doesn't identify a piece. Pawns have no letter.
+func sanPiece(ch byte) Piece {
+	switch ch {
+	case 'N':
+		return PieceKnight
+	case 'B':
+		return PieceBishop
+	case 'R':
+		return PieceRook
+	case 'Q':
+		return PieceQueen
+	case 'K':
+		return PieceKing
+	default:
+		return PieceNone
+	}
+}
+
+// sanPromoteTo returns the piece identified by the letter ch as the target of a
+// promotion in SAN, or PromoteToNone if ch doesn't identify one.
+func sanPromoteTo(ch byte) chess.PromoteTo {
+	switch ch {
+	case 'Q':
+		return chess.PromoteToQueen
+	case 'N':
+		return chess.PromoteToKnight
+	case 'R':
+		return chess.PromoteToRook
+	case 'B':
+		return chess.PromoteToBishop
+	default:
+		return chess.PromoteToNone
+	}
+}
@@ -0,0 +1,144 @@
+package engine_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/GeorgeBills/chess/engine"
+	"github.com/GeorgeBills/chess/uci"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGameSAN(t *testing.T) {
+	var tests map[string]struct {
+		FEN  string
+		Move string
+		SAN  string
+	}
+
+	f, err := os.Open("testdata/san.json")
+	require.NoError(t, err)
+	err = json.NewDecoder(f).Decode(&tests)
+	require.NoError(t, err)
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := newGameWithMoves(t, tt.FEN)
+			before := g.FEN()
+
+			parsed, err := uci.ParseUCIN(tt.Move)
+			require.NoError(t, err)
+			move, err := g.HydrateMove(parsed)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.SAN, g.SAN(move))
+			assert.Equal(t, before, g.FEN(), "SAN() should leave the board unchanged")
+		})
+	}
+}
+
+func TestParseSAN(t *testing.T) {
+	var tests map[string]struct {
+		FEN  string
+		Move string
+		SAN  string
+	}
+
+	f, err := os.Open("testdata/san.json")
+	require.NoError(t, err)
+	err = json.NewDecoder(f).Decode(&tests)
+	require.NoError(t, err)
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := newGameWithMoves(t, tt.FEN)
+
+			parsed, err := uci.ParseUCIN(tt.Move)
+			require.NoError(t, err)
+			expected, err := g.HydrateMove(parsed)
+			require.NoError(t, err)
+
+			move, err := g.ParseSAN(tt.SAN)
+			require.NoError(t, err)
+			assert.Equal(t, expected, move)
+		})
+	}
+}
+
+func TestParseSANLenient(t *testing.T) {
+	tests := []struct {
+		name     string
+		fen      string
+		san      string
+		expected string
+	}{
+		{"missing check suffix", "4k3/8/8/8/8/8/8/R3K3 w Q - 0 1", "Ra8", "a1a8"},
+		{"annotation", engine.InitialBoardFEN, "e4!?", "e2e4"},
+		{"missing capture", "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 2", "ed5", "e4d5"},
+		{"missing promotion equals", "8/P6k/8/8/8/8/8/K7 w - - 0 1", "a8Q", "a7a8q"},
+		{"castling with zeros", "r1bqk1nr/pppp1ppp/2n5/2b1p3/2B1P3/5N2/PPPP1PPP/RNBQK2R w KQkq - 4 4", "0-0", "e1g1"},
+		{"needless disambiguation", engine.InitialBoardFEN, "Ng1f3", "g1f3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newGameWithMoves(t, tt.fen)
+
+			parsed, err := uci.ParseUCIN(tt.expected)
+			require.NoError(t, err)
+			expected, err := g.HydrateMove(parsed)
+			require.NoError(t, err)
+
+			move, err := g.ParseSAN(tt.san)
+			require.NoError(t, err)
+			assert.Equal(t, expected, move)
+		})
+	}
+}
+
+func TestParseSANErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		fen      string
+		san      string
+		expected error
+	}{
+		{"empty", engine.InitialBoardFEN, "", engine.ErrMalformedSAN},
+		{"piece only", engine.InitialBoardFEN, "N", engine.ErrMalformedSAN},
+		{"invalid square", engine.InitialBoardFEN, "Ni3", engine.ErrMalformedSAN},
+		{"invalid piece", engine.InitialBoardFEN, "Xf3", engine.ErrMalformedSAN},
+		{"trailing junk", engine.InitialBoardFEN, "e4e4e4", engine.ErrMalformedSAN},
+		{"no piece can move there", engine.InitialBoardFEN, "Nd4", engine.ErrIllegalMove},
+		{"blocked by own piece", engine.InitialBoardFEN, "Ke2", engine.ErrIllegalMove},
+		{"capture of empty square", engine.InitialBoardFEN, "Nxf3", engine.ErrIllegalMove},
+		{"castling through pieces", engine.InitialBoardFEN, "O-O", engine.ErrIllegalMove},
+		{"missing promotion", "8/P6k/8/8/8/8/8/K7 w - - 0 1", "a8", engine.ErrIllegalMove},
+		{"pinned piece", "4r2k/8/8/8/8/2N1N3/8/4K3 w - - 0 1", "Ned5", engine.ErrIllegalMove},
+		{"wrong side to move", engine.InitialBoardFEN, "e5", engine.ErrIllegalMove},
+		{"ambiguous knights", "4k3/8/8/8/8/8/8/1N2KN2 w - - 0 1", "Nd2", engine.ErrAmbiguousSAN},
+		{"ambiguous queens", "6k1/8/8/7K/8/Q7/8/Q1Q5 w - - 0 1", "Qab2", engine.ErrAmbiguousSAN},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newGameWithMoves(t, tt.fen)
+			_, err := g.ParseSAN(tt.san)
+			assert.True(t, errors.Is(err, tt.expected), "expected %v, got %v", tt.expected, err)
+		})
+	}
+}
+
+func TestParseSANRoundTrip(t *testing.T) {
+	for _, fen := range []string{engine.InitialBoardFEN, kiwipeteFEN, "6k1/8/8/7K/8/Q7/8/Q1Q5 w - - 0 1"} {
+		g := newGameWithMoves(t, fen)
+		moves, _ := g.GenerateLegalMoves(nil)
+		for _, m := range moves {
+			san := g.SAN(m)
+			parsed, err := g.ParseSAN(san)
+			if assert.NoError(t, err, san) {
+				assert.Equal(t, m, parsed, san)
+			}
+		}
+	}
+}
@@ -3,25 +3,93 @@ package engine
 import (
 	"fmt"
 	"math"
+	"sort"
 	"time"
+
+	"github.com/GeorgeBills/chess"
 )
 
 // https://www.chessprogramming.org/Search
 // https://www.chessprogramming.org/Iterative_Deepening
 // https://www.chessprogramming.org/Alpha-Beta
+// https://www.chessprogramming.org/Transposition_Table
+// https://www.chessprogramming.org/Quiescence_Search
+// https://www.chessprogramming.org/Move_Ordering
 
-const (
-	maximizing = +1
-	minimizing = -1
-	infinity   = math.MaxInt16
-)
+const infinity = math.MaxInt16
+
+// Checkmate is scored as infinity less the number of plies from the root to the
+// mate, so that nearer mates score further from zero: the search prefers to
+// mate sooner and to be mated later. Any score beyond mateThreshold is a mate.
+const mateThreshold = infinity - math.MaxUint8
+
+// matedScore returns the score for being checkmated ply plies from the root.
+func matedScore(ply uint8) int16 { return -infinity + int16(ply) }
+
+// drawScore returns the score for a draw ply plies from the root, from the
+// perspective of the side to move. With contempt the side to move at the root
+// scores a draw as slightly worse than even, and so its opponent as slightly
+// better.
+func (g *Game) drawScore(ply uint8) int16 {
+	if ply%2 == 0 {
+		return -g.contempt
+	}
+	return +g.contempt
+}
 
-func (g *Game) getMaximizingMinimizing() int8 {
+// isMate returns true if score means that either side has a forced mate.
+func isMate(score int16) bool { return score >= mateThreshold || score <= -mateThreshold }
+
+// mateIn returns the number of moves until mate for a score from the
+// perspective of the side to move: positive if the side to move is mating,
+// negative if it's being mated, and 0 if the score isn't a mate.
+func mateIn(score int16) int {
+	switch {
+	case score >= mateThreshold:
+		return (int(infinity-score) + 1) / 2
+	case score <= -mateThreshold:
+		return -(int(infinity+score) + 1) / 2
+	default:
+		return 0
+	}
+}
+
+// scoreToTT converts a score from the perspective of the root into one from the
+// perspective of the position ply plies from the root, for the transposition
+// table. The same position may be reached at different plies, so mate scores
+// are stored as the distance to mate from the position itself.
+func scoreToTT(score int16, ply uint8) int16 {
+	switch {
+	case score >= mateThreshold:
+		return score + int16(ply)
+	case score <= -mateThreshold:
+		return score - int16(ply)
+	default:
+		return score
+	}
+}
+
+// scoreFromTT is the inverse of scoreToTT.
+func scoreFromTT(score int16, ply uint8) int16 {
+	switch {
+	case score >= mateThreshold:
+		return score - int16(ply)
+	case score <= -mateThreshold:
+		return score + int16(ply)
+	default:
+		return score
+	}
+}
+
+// colourSign returns +1 if white is to move and -1 if black is to move.
+// Evaluate() scores from whites perspective, so multiplying by colourSign
+// converts its score to be from the perspective of the side to move.
+func (g *Game) colourSign() int16 {
 	switch g.ToMove() {
 	case White:
-		return maximizing
+		return +1
 	case Black:
-		return minimizing
+		return -1
 	default:
 		panic(fmt.Errorf("invalid to move; %#v", g))
 	}
@@ -32,106 +100,703 @@ type moveScore struct {
 	score int16
 }
 
+// rootLine is one of the lines found by searching the root: a move with its
+// score, and the principal variation starting with that move.
+type rootLine struct {
+	moveScore
+	pv []Move
+}
+
+// SearchStatus reports on the progress of a search. It's sent after each
+// iteration of iterative deepening completes, and periodically in between to
+// report which move at the root is currently being searched.
 type SearchStatus struct {
 	Depth              uint8
+	SelectiveDepth     uint8 // the deepest ply reached, including quiescence search
+	Score              int16 // from the perspective of the side to move
+	MateIn             int   // moves until mate, as returned by mateIn, or 0 for no mate
 	Time               time.Duration
 	PrincipalVariation []Move
+	Nodes              uint64
 	NodesPerSecond     uint64
+	HashFull           int  // how full the transposition table is, in permille
+	CurrentMove        Move // the move at the root currently being searched, if any
+	CurrentMoveNumber  int  // the 1-indexed position of CurrentMove in the move order
+	MultiPV            int  // the 1-indexed rank of this line, if searching for more than one
 }
 
+// BestMoveInfinite searches with iterative deepening until told to stop,
+// returning the best move (with its score) from the deepest completed search.
+// The score is from whites perspective.
 func (g *Game) BestMoveInfinite(stopch <-chan struct{}, statusch chan<- SearchStatus) (Move, int16) {
 	defer close(statusch)
+	best := g.iterativeDeepening(searchLimits{}, stopch, statusch)
+	return best.move, best.score * g.colourSign()
+}
 
-	mm := g.getMaximizingMinimizing()
-	var best moveScore
-	var depth uint8
+// BestMoveToTime searches with iterative deepening for as long as the time
+// controls allow, returning the best move (with its score) from the deepest
+// completed search. movesToGo is the number of moves until the next time
+// control, or 0 if there isn't one. The search may also be told to stop early.
+// The score is from whites perspective.
+func (g *Game) BestMoveToTime(whiteTime, blackTime, whiteIncrement, blackIncrement time.Duration, movesToGo uint, stopch <-chan struct{}, statusch chan<- SearchStatus) (Move, int16) {
+	defer close(statusch)
+	soft, hard := g.TimeBudget(whiteTime, blackTime, whiteIncrement, blackIncrement, movesToGo)
+	limits := searchLimits{soft: soft, hard: hard, stopOnMate: true}
+	best := g.iterativeDeepening(limits, stopch, statusch)
+	return best.move, best.score * g.colourSign()
+}
+
+// BestMoveToMoveTime searches with iterative deepening for exactly movetime,
+// returning the best move (with its score) from the deepest completed search.
+// The search may also be told to stop early. The score is from whites
+// perspective.
+func (g *Game) BestMoveToMoveTime(movetime time.Duration, stopch <-chan struct{}, statusch chan<- SearchStatus) (Move, int16) {
+	defer close(statusch)
+	best := g.iterativeDeepening(searchLimits{hard: movetime}, stopch, statusch)
+	return best.move, best.score * g.colourSign()
+}
+
+// BestMovePondering searches with iterative deepening while the opponent is
+// thinking, on the position after the move we expect them to play. It searches
+// without limit until either it's told to stop or ponderhitch is closed, which
+// means that the opponent played the expected move. From then on the search
+// runs within the soft and hard budgets (as returned by TimeBudget), measured
+// from the ponderhit; zero budgets mean there is no limit. The search never
+// returns before being told to stop or ponderhitch being closed, even if it
+// finishes early. The score is from whites perspective.
+func (g *Game) BestMovePondering(soft, hard time.Duration, ponderhitch, stopch <-chan struct{}, statusch chan<- SearchStatus) (Move, int16) {
+	defer close(statusch)
+	limits := searchLimits{soft: soft, hard: hard, stopOnMate: true, ponderhitch: ponderhitch}
+	best := g.iterativeDeepening(limits, stopch, statusch)
+	return best.move, best.score * g.colourSign()
+}
+
+// BestMoveToMate searches for a forced mate in at most the given number of
+// (full) moves, stopping as soon as it finds one. If there is no such mate it
+// returns the best move from searching to that depth. Since quiescence search
+// extends the search past that depth it may also find longer mates, where the
+// mating side only captures and the other side only evades check. If movetime
+// is non-zero the search stops after that long, whether or not it's found a
+// mate. The search may also be told to stop early. The score is from whites
+// perspective.
+func (g *Game) BestMoveToMate(moves uint8, movetime time.Duration, stopch <-chan struct{}, statusch chan<- SearchStatus) (Move, int16) {
+	defer close(statusch)
+
+	// mate in n moves happens on our nth move, after 2n-1 plies
+	depth := 2*int(moves) - 1
+	if depth < 1 {
+		depth = 1
+	}
+	if depth >= maxPly {
+		depth = maxPly - 1
+	}
+
+	limits := searchLimits{depth: uint8(depth), hard: movetime, stopOnMate: true}
+	best := g.iterativeDeepening(limits, stopch, statusch)
+	return best.move, best.score * g.colourSign()
+}
+
+// BestMoveToNodes searches with iterative deepening until it has visited the
+// given number of nodes, returning the best move (with its score) from the
+// deepest completed search. The search never looks at the clock, so starting
+// from the same game state (including the transposition table) a single
+// threaded search always returns the same result, regardless of how fast the
+// machine is. Only the nodes visited by the first thread count towards the
+// limit. The search may also be told to stop early. The score is from whites
+// perspective.
+func (g *Game) BestMoveToNodes(nodes uint64, stopch <-chan struct{}, statusch chan<- SearchStatus) (Move, int16) {
+	defer close(statusch)
+	best := g.iterativeDeepening(searchLimits{nodes: nodes}, stopch, statusch)
+	return best.move, best.score * g.colourSign()
+}
+
+// BestMoveToDepth returns the best move (with its score) to the given depth.
+// The score is from whites perspective: positive if white is winning, negative
+// if black is winning. The status of the completed search is sent to statusch,
+// if it's not nil. Depths past the deepest the search can go are clamped.
+func (g *Game) BestMoveToDepth(depth uint8, stopch <-chan struct{}, statusch chan<- SearchStatus) (Move, int16) {
+	if depth >= maxPly {
+		depth = maxPly - 1
+	}
+
+	g.newSearch()
+	g.startHelpers()
+	lines := g.searchLines(depth, stopch, nil)
+	g.stopHelpers()
+	g.sendLines(statusch, depth, lines)
+	best := lines[0]
+	return best.move, best.score * g.colourSign()
+}
+
+// searchLimits bounds an iterative deepening search. The zero value of each
+// field means there is no limit.
+type searchLimits struct {
+	depth      uint8         // don't search deeper than this many plies
+	soft       time.Duration // don't start a new iteration after this long
+	hard       time.Duration // stop the search after this long
+	nodes      uint64        // stop the search after visiting this many nodes
+	stopOnMate bool          // stop as soon as either side has a forced mate
 
-DEEPEN:
-	for depth = 0; ; depth++ {
+	// ponderhitch, if not nil, means the search is pondering: the time limits
+	// only start once it's closed, and the search won't return until either
+	// it's closed or the search is told to stop.
+	ponderhitch <-chan struct{}
+}
+
+// iterativeDeepening repeatedly searches the current position one ply deeper
+// than the last time, until told to stop or until the limits are reached. It
+// returns the best move and its score from the perspective of the side to move
+// from the deepest search that completed.
+//
+// Status is sent to statusch (if it's not nil) at the start of each iteration.
+func (g *Game) iterativeDeepening(limits searchLimits, stopch <-chan struct{}, statusch chan<- SearchStatus) moveScore {
+	g.newSearch()
+	g.maxNodes = limits.nodes
+	g.startHelpers()
+	defer g.stopHelpers()
+
+	// the clock starts now, unless we're pondering, in which case it starts
+	// on the ponderhit; start is only set once clockch has been closed
+	start := g.searchStart
+	clockch := make(chan struct{})
+	if limits.ponderhitch == nil {
+		close(clockch)
+	}
+
+	if limits.hard > 0 || limits.ponderhitch != nil {
+		// stop on whichever comes first: being told to, or the hard limit
+		done := make(chan struct{})
+		defer close(done)
+		deadlinech := make(chan struct{}, 1)
+		go func(stopch <-chan struct{}) {
+			if limits.ponderhitch != nil {
+				select {
+				case <-stopch:
+					deadlinech <- struct{}{}
+					return
+				case <-limits.ponderhitch:
+					start = time.Now()
+					close(clockch)
+				case <-done:
+					return
+				}
+			}
+			var timeout <-chan time.Time
+			if limits.hard > 0 {
+				timer := time.NewTimer(limits.hard)
+				defer timer.Stop()
+				timeout = timer.C
+			}
+			select {
+			case <-stopch:
+			case <-timeout:
+			case <-done:
+				return
+			}
+			deadlinech <- struct{}{}
+		}(stopch)
+		stopch = deadlinech
+	}
+
+	var best rootLine
+	var completed uint8
+	for depth := uint8(1); depth < maxPly && (limits.depth == 0 || depth <= limits.depth); depth++ {
+		if limits.soft > 0 && depth > 1 && isClosed(clockch) && time.Since(start) >= limits.soft {
+			break // not enough time to finish another iteration
+		}
+
+		lines := g.searchLines(depth, stopch, statusch)
+		if g.stopped {
+			// the search at this depth was interrupted, so its result can't
+			// be trusted; use the result from the previous depth, if any
+			if completed == 0 {
+				best = lines[0]
+				g.sendLines(statusch, depth, lines)
+			}
+			break
+		}
+		best, completed = lines[0], depth
+		g.sendLines(statusch, depth, lines)
+
+		if limits.stopOnMate && isMate(best.score) {
+			break // searching deeper won't change a forced mate
+		}
+	}
+
+	if completed > 0 {
+		// an interrupted iteration leaves behind a partial principal
+		// variation that may not even start with the best move
+		g.setPrincipalVariation(best.pv)
+	}
+
+	if !g.stopped {
+		// we finished early; if we're pondering then wait for the ponderhit,
+		// and if there are no limits at all then wait to be told to stop
+		waitch := clockch
+		if limits == (searchLimits{}) {
+			waitch = nil
+		}
 		select {
+		case <-waitch:
 		case <-stopch:
-			break DEEPEN
-		default:
-			// spiral out, keep going.
-			statusch <- SearchStatus{Depth: depth}
-			best = g.bestMoveToDepth(depth, mm, stopch, statusch)
 		}
 	}
 
-	return best.move, best.score
+	return best.moveScore
 }
 
-func (g *Game) BestMoveToTime(whiteTime, blackTime, whiteIncrement, blackIncrement time.Duration, stopch <-chan struct{}, statusch chan<- SearchStatus) (Move, int16) {
-	return g.BestMoveToDepth(4, stopch, statusch)
-	// TODO: properly implement basic time controls
-	//
-	// http://www.chessgames.com/chessstats.html
-	// average game is 40 full moves (80 half moves) long
-	//
-	// asymptote from some max towards 0 for flat time controls
-	// asymptote from some max towards increment if incremented
-	//
-	// expected average game length, based on number of moves played
-	// games that go for 10 moves, on average go for another X moves...
-	// games that go for 20 moves, on average go for another Y moves...
-	// games that go for 30 moves, on average go for another Z moves...
-	// https://chess.stackexchange.com/a/4899:
-	//     59.3 + (72830 - 2330 k)/(2644 + k (10 + k))
-	//
-	// bump time if evaluations are unstable, the opposite if they're stable
+// isClosed returns true if ch has been closed.
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
 }
 
-// BestMoveToDepth returns the best move (with its score) to the given depth.
-func (g *Game) BestMoveToDepth(depth uint8, stopch <-chan struct{}, statusch chan<- SearchStatus) (Move, int16) {
-	mm := g.getMaximizingMinimizing()
-	best := g.bestMoveToDepth(depth, mm, stopch, statusch)
-	return best.move, best.score
+// currentMoveDelay is how long the search runs before it starts reporting
+// which move at the root it's currently searching. Before this every iteration
+// completes so quickly that the reports would just be noise.
+const currentMoveDelay = 1 * time.Second
+
+// sendLines sends the status of the search, having just searched to depth and
+// found lines, to statusch (if it's not nil). The status of each line is sent
+// separately, best first.
+func (g *Game) sendLines(statusch chan<- SearchStatus, depth uint8, lines []rootLine) {
+	if statusch == nil {
+		return
+	}
+	for i, line := range lines {
+		status := g.status(depth, line)
+		if g.multiPV > 1 {
+			status.MultiPV = i + 1
+		}
+		statusch <- status
+	}
 }
 
-func (g *Game) bestMoveToDepth(depth uint8, mm int8, stopch <-chan struct{}, statusch chan<- SearchStatus) moveScore {
-	if depth == 0 {
-		score := g.Evaluate()
-		return moveScore{score: score}
+// status returns the status of the search, having just searched to depth and
+// found line.
+func (g *Game) status(depth uint8, line rootLine) SearchStatus {
+	elapsed := time.Since(g.searchStart)
+	status := SearchStatus{
+		Depth:              depth,
+		SelectiveDepth:     g.selDepth,
+		Score:              line.score,
+		MateIn:             mateIn(line.score),
+		Time:               elapsed,
+		PrincipalVariation: line.pv,
+		Nodes:              g.Nodes(),
+	}
+	if elapsed > 0 {
+		status.NodesPerSecond = uint64(float64(status.Nodes) / elapsed.Seconds())
+	}
+	if g.tt != nil {
+		status.HashFull = g.tt.hashFull()
+	}
+	return status
+}
+
+// PrincipalVariation returns the principal variation (the sequence of moves
+// the search expects to be played) from the most recent search.
+func (g *Game) PrincipalVariation() []Move {
+	pv := make([]Move, g.pvLength[0])
+	copy(pv, g.pv[0][:g.pvLength[0]])
+	return pv
+}
+
+// setPrincipalVariation sets pv as the principal variation at the root.
+func (g *Game) setPrincipalVariation(pv []Move) {
+	copy(g.pv[0][:], pv)
+	g.pvLength[0] = uint8(len(pv))
+}
+
+// updatePV records m followed by the principal variation from the next ply as
+// the principal variation at ply.
+func (g *Game) updatePV(ply uint8, m Move) {
+	g.pv[ply][ply] = m
+	n := ply + 1
+	if ply+1 < maxPly {
+		n = g.pvLength[ply+1]
+		copy(g.pv[ply][ply+1:n], g.pv[ply+1][ply+1:n])
 	}
+	g.pvLength[ply] = n
+}
+
+// Nodes returns the number of nodes visited by the most recent search, across
+// all threads.
+func (g *Game) Nodes() uint64 { return g.nodes + g.helpers.visited() }
 
+// checkStop returns true if the search should stop, either because it's been
+// told to or because it's visited as many nodes as it's allowed to. Once the
+// search has stopped checkStop will always return true, until the next search.
+func (g *Game) checkStop(stopch <-chan struct{}) bool {
 	select {
 	case <-stopch:
-		score := g.Evaluate()
-		return moveScore{score: score}
+		g.stopped = true
 	default:
 	}
+	if g.maxNodes > 0 && g.nodes >= g.maxNodes {
+		g.stopped = true
+	}
+	return g.stopped
+}
+
+// hashMove returns the best move stored in the transposition table for the
+// current position, or 0 if there isn't one.
+func (g *Game) hashMove() Move {
+	if g.tt == nil {
+		return 0
+	}
+	e, _ := g.tt.probe(g.hash)
+	return e.move
+}
+
+// newSearch resets per-search state ahead of starting a new search.
+func (g *Game) newSearch() {
+	g.stopped = false
+	g.nodes = 0
+	g.maxNodes = 0
+	g.killers = [maxPly][2]Move{}
+	g.ageHistory()
+	g.pvLength = [maxPly]uint8{}
+	g.selDepth = 0
+	g.searchStart = time.Now()
+	g.helpers = nil
+	if g.tt != nil {
+		g.tt.newSearch()
+	}
+}
+
+// searchLines searches the current position to depth, returning the best lines
+// found, best first: one for each of the best g.multiPV moves at the root, or
+// just the best if multiPV isn't set. There may be fewer lines than that if
+// there are fewer moves, or if the search is stopped, but there's always at
+// least one. The principal variation is left as that of the best line.
+//
+// Each line after the first is found by searching again without the moves at
+// the root of the lines found so far.
+func (g *Game) searchLines(depth uint8, stopch <-chan struct{}, statusch chan<- SearchStatus) []rootLine {
+	n := g.multiPV
+	if n < 1 {
+		n = 1
+	}
+
+	lines := make([]rootLine, 0, n)
+	var exclude []Move
+	for len(lines) < n {
+		result, ok := g.searchRoot(depth, exclude, stopch, statusch)
+		if !ok || g.stopped && len(lines) > 0 {
+			break // no moves left, or the line was interrupted
+		}
+		lines = append(lines, rootLine{result, g.PrincipalVariation()})
+		if g.stopped || result.move == 0 {
+			break // interrupted, or there's no move at the root to exclude
+		}
+		exclude = append(exclude, result.move)
+	}
+
+	// a later line may score better than an earlier one, since the search
+	// isn't perfectly stable
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].score > lines[j].score })
+	g.setPrincipalVariation(lines[0].pv)
+	return lines
+}
+
+// searchRoot searches the current position to depth, returning the best move
+// and its score from the perspective of the side to move. Moves in exclude
+// aren't searched, and if that leaves no moves to search then it returns false.
+// Once the search has run for long enough it reports each move as it's searched
+// to statusch (if it's not nil).
+//
+// Ties between equally scored moves are broken in favour of the move generated
+// last, regardless of the order moves are searched in. Each move after the
+// first is searched with a window just below the best score so far, which is
+// enough to learn if it scores the same.
+func (g *Game) searchRoot(depth uint8, exclude []Move, stopch <-chan struct{}, statusch chan<- SearchStatus) (moveScore, bool) {
+	g.pvLength[0] = 0
+
+	if depth == 0 {
+		return moveScore{score: g.quiesce(-infinity, +infinity, 0, 0, stopch)}, true
+	}
+
+	g.nodes++
 
 	moves, isCheck := g.GenerateLegalMoves(nil)
+	if len(moves) == 0 {
+		if isCheck {
+			return moveScore{score: matedScore(0)}, true // checkmate
+		}
+		return moveScore{score: g.drawScore(0)}, true // stalemate
+	}
+	all := len(moves)
+	moves = excludeMoves(g.restrictRootMoves(moves), exclude)
+	if len(moves) == 0 {
+		return moveScore{}, false
+	}
 
-	var best moveScore
+	generated := make(map[Move]int, len(moves))
+	for i, m := range moves {
+		generated[m] = i
+	}
+	g.orderMoves(moves, g.hashMove(), 0)
 
-	switch mm {
-	case maximizing:
-		best.score = -1 * infinity
-		if len(moves) == 0 && !isCheck {
-			return moveScore{score: 0} // stalemate
+	best := moveScore{score: -infinity}
+	bestGenerated := -1
+	for i, m := range moves {
+		if statusch != nil && time.Since(g.searchStart) > currentMoveDelay {
+			statusch <- SearchStatus{Depth: depth, CurrentMove: m, CurrentMoveNumber: i + 1}
 		}
-		for _, m := range moves {
-			g.MakeMove(m)
-			if child := g.bestMoveToDepth(depth-1, mm*-1, stopch, statusch); child.score >= best.score {
-				best = moveScore{m, child.score}
+		alpha := best.score
+		if alpha > -infinity {
+			alpha--
+		}
+		g.MakeMove(m)
+		score := -g.negamax(depth-1, 1, -infinity, -alpha, stopch)
+		g.UnmakeMove()
+		if g.stopped {
+			// the score for an interrupted move can't be trusted, but if no
+			// move has been searched yet then any move is better than none
+			if best.move == 0 {
+				best = moveScore{m, score}
 			}
-			g.UnmakeMove()
+			break
 		}
-	case minimizing:
-		best.score = +1 * infinity
-		if len(moves) == 0 && !isCheck {
-			return moveScore{score: 0} // stalemate
+		if score > best.score || score == best.score && generated[m] > bestGenerated {
+			best = moveScore{m, score}
+			bestGenerated = generated[m]
+			g.updatePV(0, m)
 		}
-		for _, m := range moves {
-			g.MakeMove(m)
-			if child := g.bestMoveToDepth(depth-1, mm*-1, stopch, statusch); child.score <= best.score {
-				best = moveScore{m, child.score}
+	}
+
+	// a restricted search doesn't tell us the true score for the position
+	if g.tt != nil && !g.stopped && len(moves) == all {
+		g.tt.store(g.hash, best.move, best.score, depth, boundExact)
+	}
+
+	return best, true
+}
+
+// excludeMoves returns moves without any of the moves in exclude.
+func excludeMoves(moves, exclude []Move) []Move {
+	if len(exclude) == 0 {
+		return moves
+	}
+	kept := moves[:0]
+next:
+	for _, m := range moves {
+		for _, e := range exclude {
+			if m == e {
+				continue next
 			}
-			g.UnmakeMove()
 		}
-	default:
-		panic("mm neither minimizing nor maximizing")
+		kept = append(kept, m)
+	}
+	return kept
+}
+
+// restrictRootMoves returns those of the legal moves that the search has been
+// restricted to by SetSearchMoves, or all of them if there's no restriction (or
+// none of the moves it's restricted to are legal).
+func (g *Game) restrictRootMoves(legal []Move) []Move {
+	if len(g.searchMoves) == 0 {
+		return legal
+	}
+	restricted := make([]Move, 0, len(g.searchMoves))
+	for _, m := range legal {
+		for _, sm := range g.searchMoves {
+			if m == sm {
+				restricted = append(restricted, m)
+				break
+			}
+		}
+	}
+	if len(restricted) == 0 {
+		return legal
+	}
+	return restricted
+}
+
+// negamax returns the score for the current position from the perspective of
+// the side to move, searching to depth with alpha-beta pruning. ply is the
+// number of plies from the root. The score is "fail-soft": if it's at or below
+// alpha it's an upper bound on the true score, and if it's at or above beta
+// it's a lower bound.
+func (g *Game) negamax(depth, ply uint8, alpha, beta int16, stopch <-chan struct{}) int16 {
+	g.pvLength[ply] = ply
+
+	if depth == 0 {
+		return g.quiesce(alpha, beta, ply, 0, stopch)
+	}
+
+	if g.checkStop(stopch) {
+		return g.Evaluate() * g.colourSign()
+	}
+
+	g.nodes++
+	if ply > g.selDepth {
+		g.selDepth = ply
+	}
+
+	if g.isRepetition() || g.IsInsufficientMaterial() {
+		return g.drawScore(ply)
+	}
+
+	alphaOriginal := alpha
+
+	var hashMove Move
+	if g.tt != nil {
+		if e, ok := g.tt.probe(g.hash); ok {
+			if e.depth >= depth {
+				score := scoreFromTT(e.score, ply)
+				switch {
+				case e.bound == boundExact,
+					e.bound == boundLower && score >= beta,
+					e.bound == boundUpper && score <= alpha:
+					return score
+				}
+			}
+			hashMove = e.move
+		}
+	}
+
+	moves, isCheck := g.GenerateLegalMoves(nil)
+	if len(moves) == 0 {
+		if isCheck {
+			return matedScore(ply) // checkmate
+		}
+		return g.drawScore(ply) // stalemate
+	}
+
+	if g.IsFiftyMoveRule() {
+		return g.drawScore(ply) // unless checkmated above
+	}
+
+	g.orderMoves(moves, hashMove, ply)
+
+	var best moveScore
+	for i, m := range moves {
+		g.MakeMove(m)
+		score := -g.negamax(depth-1, ply+1, -beta, -alpha, stopch)
+		g.UnmakeMove()
+		if g.stopped {
+			break
+		}
+		if i == 0 || score > best.score {
+			best = moveScore{m, score}
+		}
+		if score > alpha {
+			alpha = score
+			g.updatePV(ply, m)
+		}
+		if alpha >= beta {
+			g.recordCutoff(m, ply, depth)
+			break // cutoff: our opponent will never let us reach this position
+		}
+	}
+
+	if g.tt != nil && !g.stopped {
+		b := boundExact
+		switch {
+		case best.score <= alphaOriginal:
+			b = boundUpper
+		case best.score >= beta:
+			b = boundLower
+		}
+		g.tt.store(g.hash, best.move, scoreToTT(best.score, ply), depth, b)
+	}
+
+	return best.score
+}
+
+// maxQuiescenceEvasionPly is the number of plies into quiescence search for
+// which we'll search every move to evade check. Past this we stand pat even if
+// in check, which guarantees that sequences of checks always terminate.
+const maxQuiescenceEvasionPly = 4
+
+// quiesce returns the score for the current position from the perspective of
+// the side to move, searching only captures and queen promotions (or every
+// move, if we're in check) until the position is "quiet". This extends the
+// search past depth 0 to avoid the horizon effect, where a capture looks good
+// only because the search stops before the recapture. ply is the number of
+// plies from the root, and qply the number of plies into quiescence search.
+//
+// The side to move may "stand pat" and decline to capture at all, so the
+// static evaluation is a lower bound on the score.
+func (g *Game) quiesce(alpha, beta int16, ply, qply uint8, stopch <-chan struct{}) int16 {
+	if g.noQuiescence {
+		g.nodes++
+		return g.Evaluate() * g.colourSign()
+	}
+
+	if g.checkStop(stopch) {
+		return g.Evaluate() * g.colourSign()
+	}
+
+	g.nodes++
+	if ply > g.selDepth {
+		g.selDepth = ply
+	}
+
+	// captures can't repeat a position or prolong the game past the fifty
+	// move rule, but they can leave too little material to mate
+	if g.IsInsufficientMaterial() {
+		return g.drawScore(ply)
+	}
+
+	moves, isCheck := g.GenerateLegalMoves(nil)
+	evasions := isCheck && qply < maxQuiescenceEvasionPly
+
+	if len(moves) == 0 {
+		if isCheck {
+			return matedScore(ply) // checkmate
+		}
+		return g.drawScore(ply) // stalemate
+	}
+
+	var best int16 = -infinity
+	if !evasions {
+		best = g.Evaluate() * g.colourSign() // stand pat
+		if best >= beta {
+			return best
+		}
+		if best > alpha {
+			alpha = best
+		}
+	}
+
+	// only search captures and queen promotions (unless evading check), most
+	// promising first
+	var scores [maxMoves]int32
+	n := 0
+	for _, m := range moves {
+		if !evasions && !m.IsCapture() && m.PromoteTo() != chess.PromoteToQueen {
+			continue // quiet move
+		}
+		moves[n], scores[n] = m, int32(g.scoreMVVLVA(m))
+		n++
+	}
+	moves = moves[:n]
+	sortMoves(moves, scores[:n])
+
+	for _, m := range moves {
+		g.MakeMove(m)
+		score := -g.quiesce(-beta, -alpha, ply+1, qply+1, stopch)
+		g.UnmakeMove()
+		if g.stopped {
+			break
+		}
+		if score > best {
+			best = score
+		}
+		if score > alpha {
+			alpha = score
+		}
+		if alpha >= beta {
+			break
+		}
 	}
 
 	return best
@@ -1,64 +1,253 @@
 package engine_test
 
 import (
+	"fmt"
+	"math"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/GeorgeBills/chess"
 	"github.com/GeorgeBills/chess/engine"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+var bestMoveTests = []struct {
+	name     string
+	fen      string
+	depth    uint8
+	expected string
+}{
+	{
+		"depth 0",
+		engine.InitialBoardFEN,
+		0,
+		"-",
+	},
+	{
+		"depth 1: capture queen",
+		"3q3k/8/8/8/8/8/8/3QK3 w - - 0 1",
+		1,
+		"d1xd8",
+	},
+	{
+		"depth 2: mate in one",
+		"5k2/4ppp1/8/8/8/8/8/R2bK3 w Q - 0 1",
+		2,
+		"a1a8",
+	},
+	{
+		"depth 2: must underpromote to avoid stalemate",
+		"4k3/8/8/8/r7/7K/6p1/8 b - - 0 1",
+		2,
+		"g2g1=R",
+	},
+	{
+		// based on Evans vs Reshevsky "The Mother of All Swindles"
+		"depth 3: white to force stalemate",
+		"7k/3Q4/8/1p2p2p/1P2Pn1P/5Pq1/8/7K w - - 0 1",
+		3,
+		"d7h7",
+	},
+	{
+		"depth 3: must move even if checkmate is guaranteed",
+		"4k3/8/8/8/3Pn3/8/5K2/3b3q w - - 1 15",
+		3,
+		"f2e3",
+	},
+	{
+		"depth 4: mate in two",
+		"r3k3/r5Q1/8/8/8/8/5PPR/7K b - - 0 1",
+		4,
+		"a7a1",
+	},
+}
+
 func TestBestMoveToDepth(t *testing.T) {
+	for _, tt := range bestMoveTests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := engine.NewBoardFromFEN(strings.NewReader(tt.fen))
+			require.NoError(t, err)
+			require.NotNil(t, b)
+			g := engine.NewGame(b)
+			move, _ := g.BestMoveToDepth(tt.depth, nil, nil)
+			assert.Equal(t, tt.expected, move.SAN())
+		})
+	}
+}
+
+func TestBestMoveToDepthClampsDepth(t *testing.T) {
+	b, err := engine.NewBoardFromFEN(strings.NewReader("8/8/4k3/8/8/3K4/8/8 w - - 0 1"))
+	require.NoError(t, err)
+	g := engine.NewGame(b)
+
+	statusch := make(chan engine.SearchStatus, 1)
+	move, score := g.BestMoveToDepth(math.MaxUint8, nil, statusch)
+	assert.NotZero(t, move)
+	assert.EqualValues(t, 0, score)
+	assert.Less(t, (<-statusch).Depth, uint8(math.MaxUint8), "should clamp the depth to the deepest the search can go")
+}
+
+func TestContempt(t *testing.T) {
 	tests := []struct {
 		name     string
 		fen      string
-		depth    uint8
-		expected string
+		contempt int
+		expected int16
 	}{
-		{
-			"depth 0",
-			engine.InitialBoardFEN,
-			0,
-			"-",
-		},
-		{
-			"depth 1: capture queen",
-			"3q3k/8/8/8/8/8/8/3QK3 w - - 0 1",
-			1,
-			"d1xd8",
-		},
-		{
-			"depth 2: mate in one",
-			"5k2/4ppp1/8/8/8/8/8/R2bK3 w Q - 0 1",
-			2,
-			"a1a8",
-		},
-		{
-			"depth 2: must underpromote to avoid stalemate",
-			"4k3/8/8/8/r7/7K/6p1/8 b - - 0 1",
-			2,
-			"g2g1=R",
-		},
-		{
-			// based on Evans vs Reshevsky "The Mother of All Swindles"
-			"depth 3: white to force stalemate",
-			"7k/3Q4/8/1p2p2p/1P2Pn1P/5Pq1/8/7K w - - 0 1",
-			3,
-			"d7h7",
-		},
-		{
-			"depth 3: must move even if checkmate is guaranteed",
-			"4k3/8/8/8/3Pn3/8/5K2/3b3q w - - 1 15",
-			3,
-			"f2e3",
-		},
-		{
-			"depth 4: mate in two",
-			"r3k3/r5Q1/8/8/8/8/5PPR/7K b - - 0 1",
-			4,
-			"a7a1",
-		},
+		{"no contempt", "8/8/4k3/8/8/3K4/8/8 w - - 0 1", 0, 0},
+		{"white to move", "8/8/4k3/8/8/3K4/8/8 w - - 0 1", 50, -50},
+		{"black to move", "8/8/4k3/8/8/3K4/8/8 b - - 0 1", 50, +50},
+		{"negative contempt", "8/8/4k3/8/8/3K4/8/8 w - - 0 1", -20, +20},
+		{"stalemate", "k7/8/1Q6/8/8/8/8/7K b - - 0 1", 30, +30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := engine.NewBoardFromFEN(strings.NewReader(tt.fen))
+			require.NoError(t, err)
+			g := engine.NewGame(b)
+			g.SetContempt(tt.contempt)
+			_, score := g.BestMoveToDepth(3, nil, nil)
+			assert.Equal(t, tt.expected, score)
+		})
+	}
+}
+
+func TestSetSearchMoves(t *testing.T) {
+	const fen = "3q3k/8/8/8/8/8/8/3QK3 w - - 0 1" // d1xd8 wins the queen
+
+	tests := []struct {
+		name        string
+		searchMoves []engine.Move
+		expected    []string
+	}{
+		{"unrestricted", nil, []string{"d1xd8"}},
+		{"restricted to one move", []engine.Move{engine.NewMove(engine.E1, engine.F2)}, []string{"e1f2"}},
+		{"restricted to two moves", []engine.Move{engine.NewMove(engine.D1, engine.D2), engine.NewMove(engine.D1, engine.A4)}, []string{"d1d2", "d1a4"}},
+		{"illegal moves ignored", []engine.Move{engine.NewMove(engine.D1, engine.E3), engine.NewMove(engine.E1, engine.E2)}, []string{"e1e2"}},
+		{"no legal moves", []engine.Move{engine.NewMove(engine.A1, engine.A2)}, []string{"d1xd8"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := engine.NewBoardFromFEN(strings.NewReader(fen))
+			require.NoError(t, err)
+			g := engine.NewGame(b)
+			g.SetTranspositionTable(engine.NewTranspositionTable(1))
+			g.SetSearchMoves(tt.searchMoves)
+			move, _ := g.BestMoveToDepth(3, nil, nil)
+			assert.Contains(t, tt.expected, move.SAN())
+		})
+	}
+}
+
+func TestMultiPV(t *testing.T) {
+	tests := []struct {
+		name     string
+		fen      string
+		multiPV  int
+		expected int // lines
+	}{
+		{"single", kiwipeteFEN, 1, 1},
+		{"three lines", kiwipeteFEN, 3, 3},
+		{"more lines than moves", "7k/8/8/8/8/8/6PP/6QK b - - 0 1", 5, 3},
+		{"checkmated", "k7/1Q6/1K6/8/8/8/8/8 b - - 0 1", 3, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := engine.NewBoardFromFEN(strings.NewReader(tt.fen))
+			require.NoError(t, err)
+
+			// without a transposition table the first line is searched
+			// exactly as it would be for a single line
+			g := engine.NewGame(b)
+			expected, expectedScore := g.BestMoveToDepth(3, nil, nil)
+
+			g.SetMultiPV(tt.multiPV)
+			statusch := make(chan engine.SearchStatus, 100)
+			move, score := g.BestMoveToDepth(3, nil, statusch)
+			close(statusch)
+			assert.Equal(t, expected, move)
+			assert.Equal(t, expectedScore, score)
+
+			var lines []engine.SearchStatus
+			for status := range statusch {
+				lines = append(lines, status)
+			}
+			require.Len(t, lines, tt.expected)
+
+			roots := make(map[engine.Move]bool)
+			for i, line := range lines {
+				if tt.multiPV > 1 {
+					assert.Equal(t, i+1, line.MultiPV)
+				} else {
+					assert.Zero(t, line.MultiPV)
+				}
+				if i > 0 {
+					assert.LessOrEqual(t, line.Score, lines[i-1].Score, "lines should be best first")
+					require.NotEmpty(t, line.PrincipalVariation)
+					assert.False(t, roots[line.PrincipalVariation[0]], "lines should start with different moves")
+				}
+				if len(line.PrincipalVariation) > 0 {
+					roots[line.PrincipalVariation[0]] = true
+				}
+			}
+			if move != 0 {
+				assert.Equal(t, move, lines[0].PrincipalVariation[0])
+			}
+		})
+	}
+}
+
+func TestThreads(t *testing.T) {
+	for _, threads := range []int{1, 2, 4} {
+		t.Run(fmt.Sprintf("%d threads", threads), func(t *testing.T) {
+			for _, tt := range bestMoveTests {
+				t.Run(tt.name, func(t *testing.T) {
+					b, err := engine.NewBoardFromFEN(strings.NewReader(tt.fen))
+					require.NoError(t, err)
+					g := engine.NewGame(b)
+					g.SetTranspositionTable(engine.NewTranspositionTable(1))
+					g.SetThreads(threads)
+					move, _ := g.BestMoveToDepth(tt.depth, nil, nil)
+					assert.Equal(t, tt.expected, move.SAN())
+				})
+			}
+		})
+	}
+}
+
+func TestThreadsIterativeDeepening(t *testing.T) {
+	b, err := engine.NewBoardFromFEN(strings.NewReader(kiwipeteFEN))
+	require.NoError(t, err)
+	g := engine.NewGame(b)
+	g.SetTranspositionTable(engine.NewTranspositionTable(1))
+	g.SetThreads(4)
+
+	statusch := make(chan engine.SearchStatus, 100)
+	move, _ := g.BestMoveToMoveTime(100*time.Millisecond, nil, statusch)
+	assert.NotZero(t, move)
+
+	var last engine.SearchStatus
+	for status := range statusch {
+		last = status
+	}
+	assert.LessOrEqual(t, last.Nodes, g.Nodes())
+	assert.Equal(t, move, last.PrincipalVariation[0])
+}
+
+func TestBestMoveToDepthAvoidsHorizonBlunders(t *testing.T) {
+	// each of these loses material just past the search horizon
+	tests := []struct {
+		name    string
+		fen     string
+		depth   uint8
+		blunder string
+	}{
+		{"queen takes defended pawn", "4k3/8/2p5/3p4/8/8/8/3QK3 w - - 0 1", 1, "d1xd5"},
+		{"knight takes defended pawn", "4k3/8/8/3p4/4p3/2N5/8/4K3 w - - 0 1", 1, "c3xe4"},
+		{"rooks trade into a doubly defended pawn", "3rk3/3r4/8/3p4/8/8/3R4/3RK3 w - - 0 1", 3, "d2xd5"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -67,25 +256,330 @@ func TestBestMoveToDepth(t *testing.T) {
 			require.NotNil(t, b)
 			g := engine.NewGame(b)
 			move, _ := g.BestMoveToDepth(tt.depth, nil, nil)
-			assert.Equal(t, tt.expected, move.SAN())
+			assert.NotEqual(t, tt.blunder, move.SAN())
+		})
+	}
+}
+
+// minimax is a plain minimax search, exploring every node to depth. It's used
+// as a reference to check that pruning in the engine search never changes the
+// result, only the number of nodes visited. Being mated ply plies from the root
+// scores ±(MaxInt16 - ply).
+func minimax(g *engine.Game, depth, ply uint8, nodes *uint64) (engine.Move, int16) {
+	*nodes++
+	if depth == 0 {
+		return 0, g.Evaluate()
+	}
+	moves, isCheck := g.GenerateLegalMoves(nil)
+	if len(moves) == 0 {
+		if isCheck {
+			return 0, (-math.MaxInt16 + int16(ply)) * colourSign(g) // checkmate
+		}
+		return 0, 0 // stalemate
+	}
+	var best engine.Move
+	var bestScore int16
+	switch g.ToMove() {
+	case engine.White:
+		bestScore = -math.MaxInt16
+		for _, m := range moves {
+			g.MakeMove(m)
+			if _, score := minimax(g, depth-1, ply+1, nodes); score >= bestScore {
+				best, bestScore = m, score
+			}
+			g.UnmakeMove()
+		}
+	case engine.Black:
+		bestScore = +math.MaxInt16
+		for _, m := range moves {
+			g.MakeMove(m)
+			if _, score := minimax(g, depth-1, ply+1, nodes); score <= bestScore {
+				best, bestScore = m, score
+			}
+			g.UnmakeMove()
+		}
+	}
+	return best, bestScore
+}
+
+// minimaxQuiescence is minimax, but evaluating with quiescence search at depth
+// 0 rather than as the position stands, as the engine search does.
+func minimaxQuiescence(g *engine.Game, depth, ply uint8, nodes *uint64) (engine.Move, int16) {
+	if depth == 0 {
+		return 0, quiescence(g, -math.MaxInt16, +math.MaxInt16, ply, 0, nodes) * colourSign(g)
+	}
+	*nodes++
+	moves, isCheck := g.GenerateLegalMoves(nil)
+	if len(moves) == 0 {
+		if isCheck {
+			return 0, (-math.MaxInt16 + int16(ply)) * colourSign(g) // checkmate
+		}
+		return 0, 0 // stalemate
+	}
+	var best engine.Move
+	var bestScore int16
+	switch g.ToMove() {
+	case engine.White:
+		bestScore = -math.MaxInt16
+		for _, m := range moves {
+			g.MakeMove(m)
+			if _, score := minimaxQuiescence(g, depth-1, ply+1, nodes); score >= bestScore {
+				best, bestScore = m, score
+			}
+			g.UnmakeMove()
+		}
+	case engine.Black:
+		bestScore = +math.MaxInt16
+		for _, m := range moves {
+			g.MakeMove(m)
+			if _, score := minimaxQuiescence(g, depth-1, ply+1, nodes); score <= bestScore {
+				best, bestScore = m, score
+			}
+			g.UnmakeMove()
+		}
+	}
+	return best, bestScore
+}
+
+// quiescence searches every capture and queen promotion (or every evasion, for
+// the first 4 plies) until the position is quiet, returning the score from the
+// perspective of the side to move. A full width capture search explodes in
+// size, so this prunes with alpha-beta; called with a full window the result is
+// exact.
+func quiescence(g *engine.Game, alpha, beta int16, ply, qply uint8, nodes *uint64) int16 {
+	*nodes++
+	moves, isCheck := g.GenerateLegalMoves(nil)
+	evasions := isCheck && qply < 4
+	if len(moves) == 0 {
+		if isCheck {
+			return -math.MaxInt16 + int16(ply)
+		}
+		return 0
+	}
+	var best int16 = -math.MaxInt16
+	if !evasions {
+		best = g.Evaluate() * colourSign(g)
+		if best > alpha {
+			alpha = best
+		}
+	}
+	// without some ordering the search explodes, so try the most valuable
+	// victims first (the piece type bits are in descending order of value)
+	sort.SliceStable(moves, func(i, j int) bool {
+		return g.PieceAt(moves[i].To())&^engine.PieceWhite&^engine.PieceBlack < g.PieceAt(moves[j].To())&^engine.PieceWhite&^engine.PieceBlack
+	})
+	for _, m := range moves {
+		if alpha >= beta {
+			break
+		}
+		if !evasions && !m.IsCapture() && m.PromoteTo() != chess.PromoteToQueen {
+			continue
+		}
+		g.MakeMove(m)
+		score := -quiescence(g, -beta, -alpha, ply+1, qply+1, nodes)
+		g.UnmakeMove()
+		if score > best {
+			best = score
+		}
+		if score > alpha {
+			alpha = score
+		}
+	}
+	return best
+}
+
+func colourSign(g *engine.Game) int16 {
+	if g.ToMove() == engine.White {
+		return +1
+	}
+	return -1
+}
+
+func TestBestMoveToDepthMatchesMinimax(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		depth uint8
+	}{
+		{"initial", engine.InitialBoardFEN, 4},
+		{"kiwipete", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 123", 3},
+		{"position 3", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 123", 4},
+		{"position 4", "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 123", 3},
+		{"position 5", "rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8", 3},
+	}
+	for _, tt := range bestMoveTests {
+		tests = append(tests, struct {
+			name  string
+			fen   string
+			depth uint8
+		}{tt.name, tt.fen, tt.depth})
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := engine.NewBoardFromFEN(strings.NewReader(tt.fen))
+			require.NoError(t, err)
+			require.NotNil(t, b)
+			g := engine.NewGame(b)
+			g.SetQuiescence(false)
+
+			var minimaxNodes uint64
+			expectedMove, expectedScore := minimax(g, tt.depth, 0, &minimaxNodes)
+			move, score := g.BestMoveToDepth(tt.depth, nil, nil)
+
+			assert.Equal(t, expectedMove.SAN(), move.SAN())
+			assert.Equal(t, expectedScore, score)
+			assert.LessOrEqual(t, g.Nodes(), minimaxNodes)
 		})
 	}
 }
 
+func TestBestMoveToDepthMatchesMinimaxQuiescence(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		depth uint8
+	}{
+		{"initial", engine.InitialBoardFEN, 4},
+		{"kiwipete", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 123", 1},
+		{"position 3", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 123", 4},
+		{"position 4", "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 123", 2},
+		{"position 5", "rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8", 2},
+	}
+	for _, tt := range bestMoveTests {
+		tests = append(tests, struct {
+			name  string
+			fen   string
+			depth uint8
+		}{tt.name, tt.fen, tt.depth})
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := engine.NewBoardFromFEN(strings.NewReader(tt.fen))
+			require.NoError(t, err)
+			require.NotNil(t, b)
+			g := engine.NewGame(b)
+
+			var minimaxNodes uint64
+			expectedMove, expectedScore := minimaxQuiescence(g, tt.depth, 0, &minimaxNodes)
+			move, score := g.BestMoveToDepth(tt.depth, nil, nil)
+
+			assert.Equal(t, expectedMove.SAN(), move.SAN())
+			assert.Equal(t, expectedScore, score)
+			assert.LessOrEqual(t, g.Nodes(), minimaxNodes)
+		})
+	}
+}
+
+func BenchmarkMinimaxVsAlphaBeta(b *testing.B) {
+	const depth = 4
+
+	g := engine.NewGame(engine.NewBoard())
+	g.SetQuiescence(false)
+
+	b.Run("minimax", func(b *testing.B) {
+		var nodes uint64
+		for i := 0; i < b.N; i++ {
+			nodes = 0
+			minimax(g, depth, 0, &nodes)
+		}
+		b.ReportMetric(float64(nodes), "nodes/op")
+	})
+
+	b.Run("alphabeta", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			g.BestMoveToDepth(depth, nil, nil)
+		}
+		b.ReportMetric(float64(g.Nodes()), "nodes/op")
+	})
+}
+
 func BenchmarkBestMoveToDepth(b *testing.B) {
 	const depth = 6
 
 	g := engine.NewGame(engine.NewBoard())
 
 	stopch := make(chan struct{})
-	statusch := make(chan engine.SearchStatus)
 
 	var move engine.Move
 	for i := 0; i < b.N; i++ {
-		move, _ = g.BestMoveToDepth(depth, stopch, statusch)
+		move, _ = g.BestMoveToDepth(depth, stopch, nil)
 	}
 	b.StopTimer()
+	b.ReportMetric(float64(g.Nodes()), "nodes/op")
 
 	// sanity check our best move
 	assert.Contains(b, []string{"g1f3", "e2e4", "d2d4", "c2c4"}, move.SAN())
 }
+
+func TestBestMoveToNodes(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		nodes uint64
+	}{
+		{"initial", engine.InitialBoardFEN, 1_000},
+		{"kiwipete", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 123", 10_000},
+		{"position 3", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 123", 50_000},
+		{"one node", engine.InitialBoardFEN, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			search := func() (engine.Move, int16, uint64) {
+				b, err := engine.NewBoardFromFEN(strings.NewReader(tt.fen))
+				require.NoError(t, err)
+				require.NotNil(t, b)
+				g := engine.NewGame(b)
+				g.SetTranspositionTable(engine.NewTranspositionTable(1))
+				g.SetThreads(1)
+				statusch := make(chan engine.SearchStatus, 100)
+				move, score := g.BestMoveToNodes(tt.nodes, nil, statusch)
+				return move, score, g.Nodes()
+			}
+
+			move, score, nodes := search()
+			assert.NotZero(t, move, "should always return a move")
+			assert.LessOrEqual(t, nodes, tt.nodes, "should not exceed the node budget")
+
+			// searching again from the same state gives the same result
+			move2, score2, nodes2 := search()
+			assert.Equal(t, move.SAN(), move2.SAN())
+			assert.Equal(t, score, score2)
+			assert.Equal(t, nodes, nodes2)
+		})
+	}
+}
+
+func TestBestMoveToMate(t *testing.T) {
+	tests := []struct {
+		name     string
+		fen      string
+		moves    uint8
+		expected string
+		mate     bool
+	}{
+		{"mate in one", "5k2/4ppp1/8/8/8/8/8/R2bK3 w Q - 0 1", 1, "a1a8", true},
+		{"mate in two", "r3k3/r5Q1/8/8/8/8/5PPR/7K b - - 0 1", 2, "a7a1", true},
+		{"mate in two, searching for mate in three", "r3k3/r5Q1/8/8/8/8/5PPR/7K b - - 0 1", 3, "a7a1", true},
+		{"no mate", engine.InitialBoardFEN, 2, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := engine.NewBoardFromFEN(strings.NewReader(tt.fen))
+			require.NoError(t, err)
+			require.NotNil(t, b)
+			g := engine.NewGame(b)
+
+			statusch := make(chan engine.SearchStatus, 100)
+			move, score := g.BestMoveToMate(tt.moves, 0, nil, statusch)
+			assert.NotZero(t, move, "should always return a move")
+
+			// mate scores are offset from ±MaxInt16 by the number of plies to mate
+			mateThreshold := int16(math.MaxInt16 - 2*int(tt.moves))
+			mate := score >= mateThreshold && g.ToMove() == engine.White || score <= -mateThreshold && g.ToMove() == engine.Black
+			assert.Equal(t, tt.mate, mate)
+			if tt.mate {
+				assert.Equal(t, tt.expected, move.SAN())
+			}
+		})
+	}
+}
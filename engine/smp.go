@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// https://www.chessprogramming.org/Lazy_SMP
+// https://www.chessprogramming.org/Shared_Hash_Table
+
+// helpers are the helper threads of a multi-threaded search. Each searches the
+// same position as the main thread, on its own copy of the game, sharing only
+// the transposition table. They never report a result; they're only there to
+// fill the table with entries that speed up the main thread's search.
+type helpers struct {
+	// nodes counts the nodes visited by all the helpers, accessed atomically.
+	// It's first in the struct to keep it 64 bit aligned.
+	nodes uint64
+
+	stopch chan struct{}
+	wg     sync.WaitGroup
+}
+
+// startHelpers starts a helper thread for each thread after the first, and
+// records them in g.helpers. It does nothing if the search is single threaded.
+func (g *Game) startHelpers() {
+	if g.threads <= 1 {
+		return
+	}
+	h := &helpers{stopch: make(chan struct{})}
+	for i := 1; i < g.threads; i++ {
+		helper := g.clone()
+		h.wg.Add(1)
+		go func(id int) {
+			defer h.wg.Done()
+			helper.help(id, h)
+		}(i)
+	}
+	g.helpers = h
+}
+
+// stopHelpers stops the helper threads, if there are any, and waits for them to
+// return.
+func (g *Game) stopHelpers() {
+	if g.helpers == nil {
+		return
+	}
+	close(g.helpers.stopch)
+	g.helpers.wg.Wait()
+}
+
+// visited returns the number of nodes visited by all the helpers so far, or 0 if
+// there aren't any.
+func (h *helpers) visited() uint64 {
+	if h == nil {
+		return 0
+	}
+	return atomic.LoadUint64(&h.nodes)
+}
+
+// help searches with iterative deepening until the helpers are stopped. Every
+// second helper starts a ply deeper than the others, so that the threads don't
+// all search the same depth at the same time.
+func (g *Game) help(id int, h *helpers) {
+	for depth := uint8(1 + id%2); depth < maxPly; depth++ {
+		g.searchRoot(depth, nil, h.stopch, nil)
+		atomic.AddUint64(&h.nodes, g.nodes)
+		g.nodes = 0
+		if g.stopped {
+			return
+		}
+	}
+}
+
+// clone returns a copy of the game for a helper thread to search, since making
+// moves modifies the board. The copy shares the transposition table and starts
+// with the same move ordering heuristics, but has no node limit.
+func (g *Game) clone() *Game {
+	b := *g.Board
+	c := *g
+	c.Board = &b
+	c.history = append(make([]moveCapture, 0, cap(g.history)), g.history...)
+	c.maxNodes = 0
+	c.helpers = nil
+	return &c
+}
@@ -0,0 +1,65 @@
+package engine
+
+import "time"
+
+// https://www.chessprogramming.org/Time_Management
+
+// moveOverhead is held back from every time budget to allow for the latency
+// between the GUI's clock and our own (process scheduling, pipes, etc).
+const moveOverhead = 50 * time.Millisecond
+
+// minMovesToGo is the fewest moves we'll ever plan to make with the remaining
+// time in sudden death, so that we never spend too much on one move.
+const minMovesToGo = 10
+
+// hardBudgetFactor is how many times over its soft budget a single iteration is
+// allowed to run before the search is stopped.
+const hardBudgetFactor = 4
+
+// expectedPliesRemaining returns how many more plies we expect a game to last,
+// given that it's already lasted for the given number of plies. Long games are
+// expected to go on for longer.
+//
+// https://chess.stackexchange.com/a/4899
+func expectedPliesRemaining(plies float64) float64 {
+	return 59.3 + (72830-2330*plies)/(2644+plies*(10+plies))
+}
+
+// TimeBudget returns the soft and hard budgets for the current move given the
+// time remaining on each clock, the increment per move for each side and the
+// number of moves until the next time control (0 if there isn't one).
+//
+// The search shouldn't start a new iteration once the soft budget has been
+// used, and must stop immediately once the hard budget has been used.
+func (g *Game) TimeBudget(whiteTime, blackTime, whiteIncrement, blackIncrement time.Duration, movesToGo uint) (soft, hard time.Duration) {
+	remaining, increment := whiteTime, whiteIncrement
+	if g.ToMove() == Black {
+		remaining, increment = blackTime, blackIncrement
+	}
+
+	available := remaining - moveOverhead
+	if available < time.Millisecond {
+		available = time.Millisecond
+	}
+
+	moves := float64(movesToGo)
+	if movesToGo == 0 {
+		moves = expectedPliesRemaining(float64(g.total)) / 2
+		if moves < minMovesToGo {
+			moves = minMovesToGo
+		}
+	}
+
+	// we'll get the increment back after this move, but spend only most of it
+	// in case the GUI's clock doesn't agree with ours
+	soft = time.Duration(float64(available)/moves) + increment*3/4
+	hard = hardBudgetFactor * soft
+
+	if hard > available {
+		hard = available
+	}
+	if soft > hard {
+		soft = hard
+	}
+	return soft, hard
+}
@@ -0,0 +1,225 @@
+package engine_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GeorgeBills/chess/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const kiwipeteFEN = "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 123"
+
+func TestBestMoveToTime(t *testing.T) {
+	tests := []struct {
+		name                 string
+		fen                  string
+		whiteTime, blackTime time.Duration
+		whiteInc, blackInc   time.Duration
+		movesToGo            uint
+	}{
+		{"sudden death", kiwipeteFEN, 1 * time.Second, 1 * time.Second, 0, 0, 0},
+		{"increment", kiwipeteFEN, 500 * time.Millisecond, 500 * time.Millisecond, 100 * time.Millisecond, 100 * time.Millisecond, 0},
+		{"moves to go", kiwipeteFEN, 2 * time.Second, 2 * time.Second, 0, 0, 20},
+		{"last move before time control", kiwipeteFEN, 300 * time.Millisecond, 1 * time.Hour, 0, 0, 1},
+		{"black to move", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R b KQkq - 0 123", 1 * time.Hour, 300 * time.Millisecond, 0, 0, 0},
+		{"almost flagged", kiwipeteFEN, 10 * time.Millisecond, 1 * time.Hour, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := engine.NewBoardFromFEN(strings.NewReader(tt.fen))
+			require.NoError(t, err)
+			require.NotNil(t, b)
+			g := engine.NewGame(b)
+
+			remaining := tt.whiteTime
+			if g.ToMove() == engine.Black {
+				remaining = tt.blackTime
+			}
+
+			_, hard := g.TimeBudget(tt.whiteTime, tt.blackTime, tt.whiteInc, tt.blackInc, tt.movesToGo)
+			assert.Less(t, int64(hard), int64(remaining), "should never budget all of the remaining time")
+
+			statusch := make(chan engine.SearchStatus, 100)
+			start := time.Now()
+			move, _ := g.BestMoveToTime(tt.whiteTime, tt.blackTime, tt.whiteInc, tt.blackInc, tt.movesToGo, nil, statusch)
+			elapsed := time.Since(start)
+
+			assert.NotZero(t, move, "should always return a move")
+			assert.Less(t, int64(elapsed), int64(hard+100*time.Millisecond), "should stop promptly once the budget is up")
+
+			var depths int
+			for range statusch {
+				depths++
+			}
+			assert.Greater(t, depths, 0, "should send status for each iteration")
+		})
+	}
+}
+
+func TestBestMoveToTimeStop(t *testing.T) {
+	b, err := engine.NewBoardFromFEN(strings.NewReader(kiwipeteFEN))
+	require.NoError(t, err)
+	g := engine.NewGame(b)
+
+	stopch := make(chan struct{}, 1)
+	statusch := make(chan engine.SearchStatus, 100)
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		stopch <- struct{}{}
+	}()
+
+	start := time.Now()
+	move, _ := g.BestMoveToTime(1*time.Hour, 1*time.Hour, 0, 0, 0, stopch, statusch)
+	elapsed := time.Since(start)
+
+	assert.NotZero(t, move)
+	assert.Less(t, int64(elapsed), int64(1*time.Second), "should stop promptly when told to")
+}
+
+func TestBestMoveInfinite(t *testing.T) {
+	// even when there's nothing left to search, an infinite search mustn't
+	// return until it's told to stop
+	const stop = 100 * time.Millisecond
+
+	tests := []struct {
+		name string
+		fen  string
+	}{
+		{"king vs king", "8/8/4k3/8/8/3K4/8/8 w - - 0 1"},
+		{"stalemate", "k7/8/1Q6/8/8/8/8/7K b - - 0 1"},
+		{"mate in one", "5k2/4ppp1/8/8/8/8/8/R2bK3 w Q - 0 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := engine.NewBoardFromFEN(strings.NewReader(tt.fen))
+			require.NoError(t, err)
+			g := engine.NewGame(b)
+
+			stopch := make(chan struct{}, 1)
+			statusch := make(chan engine.SearchStatus)
+			go func() {
+				for range statusch {
+				}
+			}()
+			go func() {
+				time.Sleep(stop)
+				stopch <- struct{}{}
+			}()
+
+			start := time.Now()
+			g.BestMoveInfinite(stopch, statusch)
+			elapsed := time.Since(start)
+
+			assert.GreaterOrEqual(t, int64(elapsed), int64(stop), "should wait to be told to stop")
+			assert.Less(t, int64(elapsed), int64(stop+1*time.Second), "should return promptly once told to stop")
+		})
+	}
+}
+
+func TestBestMoveToTimeForcedMate(t *testing.T) {
+	// with a forced mate there's no need to use the time available
+	b, err := engine.NewBoardFromFEN(strings.NewReader("5k2/4ppp1/8/8/8/8/8/R2bK3 w Q - 0 1"))
+	require.NoError(t, err)
+	g := engine.NewGame(b)
+
+	statusch := make(chan engine.SearchStatus, 100)
+	start := time.Now()
+	move, score := g.BestMoveToTime(1*time.Hour, 1*time.Hour, 0, 0, 0, nil, statusch)
+	elapsed := time.Since(start)
+
+	assert.Equal(t, "a1a8", move.SAN())
+	assert.EqualValues(t, +32767-1, score, "mate in one ply")
+	assert.Less(t, int64(elapsed), int64(1*time.Second))
+}
+
+func TestBestMoveToMoveTime(t *testing.T) {
+	for _, movetime := range []time.Duration{1 * time.Millisecond, 100 * time.Millisecond, 300 * time.Millisecond} {
+		t.Run(movetime.String(), func(t *testing.T) {
+			b, err := engine.NewBoardFromFEN(strings.NewReader(kiwipeteFEN))
+			require.NoError(t, err)
+			g := engine.NewGame(b)
+
+			statusch := make(chan engine.SearchStatus, 100)
+			start := time.Now()
+			move, _ := g.BestMoveToMoveTime(movetime, nil, statusch)
+			elapsed := time.Since(start)
+
+			assert.NotZero(t, move)
+			assert.GreaterOrEqual(t, int64(elapsed), int64(movetime), "should use all of the move time")
+			assert.Less(t, int64(elapsed), int64(movetime+100*time.Millisecond), "should stop promptly once the move time is up")
+		})
+	}
+}
+
+func TestBestMovePondering(t *testing.T) {
+	const ponder = 200 * time.Millisecond
+	const hard = 100 * time.Millisecond
+
+	b, err := engine.NewBoardFromFEN(strings.NewReader(kiwipeteFEN))
+	require.NoError(t, err)
+	g := engine.NewGame(b)
+
+	ponderhitch := make(chan struct{})
+	statusch := make(chan engine.SearchStatus, 100)
+	go func() {
+		time.Sleep(ponder)
+		close(ponderhitch)
+	}()
+
+	start := time.Now()
+	move, _ := g.BestMovePondering(hard/4, hard, ponderhitch, nil, statusch)
+	elapsed := time.Since(start)
+
+	assert.NotZero(t, move)
+	assert.GreaterOrEqual(t, int64(elapsed), int64(ponder), "should not stop before the ponderhit")
+	assert.Less(t, int64(elapsed), int64(ponder+hard+100*time.Millisecond), "should stop promptly once the budget from the ponderhit is up")
+}
+
+func TestBestMovePonderingForcedMate(t *testing.T) {
+	// even with a forced mate the search mustn't return while pondering
+	const ponder = 100 * time.Millisecond
+
+	b, err := engine.NewBoardFromFEN(strings.NewReader("5k2/4ppp1/8/8/8/8/8/R2bK3 w Q - 0 1"))
+	require.NoError(t, err)
+	g := engine.NewGame(b)
+
+	ponderhitch := make(chan struct{})
+	statusch := make(chan engine.SearchStatus, 100)
+	go func() {
+		time.Sleep(ponder)
+		close(ponderhitch)
+	}()
+
+	start := time.Now()
+	move, _ := g.BestMovePondering(1*time.Hour, 1*time.Hour, ponderhitch, nil, statusch)
+	elapsed := time.Since(start)
+
+	assert.Equal(t, "a1a8", move.SAN())
+	assert.GreaterOrEqual(t, int64(elapsed), int64(ponder), "should wait for the ponderhit")
+	assert.Less(t, int64(elapsed), int64(1*time.Second), "should return promptly after the ponderhit")
+}
+
+func TestBestMovePonderingStop(t *testing.T) {
+	b, err := engine.NewBoardFromFEN(strings.NewReader(kiwipeteFEN))
+	require.NoError(t, err)
+	g := engine.NewGame(b)
+
+	ponderhitch := make(chan struct{})
+	stopch := make(chan struct{}, 1)
+	statusch := make(chan engine.SearchStatus, 100)
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		stopch <- struct{}{}
+	}()
+
+	start := time.Now()
+	move, _ := g.BestMovePondering(1*time.Millisecond, 1*time.Millisecond, ponderhitch, stopch, statusch)
+	elapsed := time.Since(start)
+
+	assert.NotZero(t, move)
+	assert.GreaterOrEqual(t, int64(elapsed), int64(100*time.Millisecond), "should ignore the budget until the ponderhit")
+	assert.Less(t, int64(elapsed), int64(1*time.Second), "should stop promptly when told to")
+}
@@ -0,0 +1,176 @@
+package engine
+
+import "sync/atomic"
+
+// https://www.chessprogramming.org/Transposition_Table
+// https://www.chessprogramming.org/Shared_Hash_Table#Lockless
+
+// DefaultTranspositionTableMegabytes is the size in megabytes of the
+// transposition table the UCI adapter uses unless configured otherwise.
+const DefaultTranspositionTableMegabytes = 16
+
+// bound indicates how a score stored in the transposition table relates to the
+// true score for the position.
+type bound uint8
+
+const (
+	boundNone  bound = iota // entry is empty
+	boundExact              // score is exact
+	boundLower              // score is a lower bound (the search failed high)
+	boundUpper              // score is an upper bound (the search failed low)
+)
+
+// ttEntrySize is the size in bytes of a ttSlot.
+const ttEntrySize = 16
+
+// ttEntry is a single entry in the transposition table.
+type ttEntry struct {
+	hash  uint64
+	move  Move
+	score int16
+	depth uint8
+	bound bound
+	age   uint8
+}
+
+// ttSlot is where a ttEntry is stored in the table, packed into two words so
+// that as many entries as possible fit in the table.
+//
+// Concurrent searches read and write slots without locking, so a slot may be
+// read half way through being written, or be written by two searches at once.
+// Each word is read and written atomically, and key holds the hash xor data;
+// if the words come from different entries then the hash won't match, and the
+// slot is treated as empty.
+type ttSlot struct {
+	key  uint64
+	data uint64
+}
+
+// pack returns e, without its hash, packed into a single word.
+func (e ttEntry) pack() uint64 {
+	return uint64(e.move) |
+		uint64(uint16(e.score))<<16 |
+		uint64(e.depth)<<32 |
+		uint64(e.bound)<<40 |
+		uint64(e.age)<<48
+}
+
+// unpackTTEntry is the inverse of ttEntry.pack.
+func unpackTTEntry(hash, data uint64) ttEntry {
+	return ttEntry{
+		hash:  hash,
+		move:  Move(data),
+		score: int16(uint16(data >> 16)),
+		depth: uint8(data >> 32),
+		bound: bound(data >> 40),
+		age:   uint8(data >> 48),
+	}
+}
+
+// load atomically loads the entry in the slot.
+func (s *ttSlot) load() ttEntry {
+	data := atomic.LoadUint64(&s.data)
+	key := atomic.LoadUint64(&s.key)
+	return unpackTTEntry(key^data, data)
+}
+
+// save atomically saves e to the slot.
+func (s *ttSlot) save(e ttEntry) {
+	data := e.pack()
+	atomic.StoreUint64(&s.key, e.hash^data)
+	atomic.StoreUint64(&s.data, data)
+}
+
+// TranspositionTable is a fixed size hash table storing the results of
+// previous searches, keyed by the Zobrist hash of the searched position. It
+// allows the search to skip positions it has already searched to a sufficient
+// depth, whether they were reached by the same or a different move order.
+//
+// A TranspositionTable may be shared between many consecutive searches (and
+// games). It's also shared by the threads of a multi-threaded search, which is
+// safe because entries are read and written without locking (see ttSlot), but
+// it may not be shared between otherwise unrelated concurrent searches.
+type TranspositionTable struct {
+	entries []ttSlot
+	mask    uint64
+	age     uint8
+}
+
+// NewTranspositionTable returns a new transposition table taking up at most
+// the given number of megabytes. The number of entries is always a power of
+// two, so the table may be smaller than requested.
+func NewTranspositionTable(megabytes int) *TranspositionTable {
+	n := uint64(megabytes) * 1024 * 1024 / ttEntrySize
+	size := uint64(1)
+	for size*2 <= n {
+		size *= 2
+	}
+	return &TranspositionTable{
+		entries: make([]ttSlot, size),
+		mask:    size - 1,
+	}
+}
+
+// Clear empties the transposition table, e.g. for the start of a new game.
+func (tt *TranspositionTable) Clear() {
+	for i := range tt.entries {
+		tt.entries[i] = ttSlot{}
+	}
+	tt.age = 0
+}
+
+// Len returns the number of entries the table can hold.
+func (tt *TranspositionTable) Len() int { return len(tt.entries) }
+
+// newSearch marks the start of a new search. Entries stored by previous
+// searches are preferentially replaced by entries from this search.
+func (tt *TranspositionTable) newSearch() {
+	tt.age++
+}
+
+// hashFull returns how full the table is with entries from the current search,
+// in permille. Only a sample of the table is checked.
+func (tt *TranspositionTable) hashFull() int {
+	sample := len(tt.entries)
+	if sample > 1000 {
+		sample = 1000
+	}
+	n := 0
+	for i := range tt.entries[:sample] {
+		if e := tt.entries[i].load(); e.bound != boundNone && e.age == tt.age {
+			n++
+		}
+	}
+	return n * 1000 / sample
+}
+
+// probe returns the entry for the position with the given hash, if there is
+// one.
+func (tt *TranspositionTable) probe(hash uint64) (ttEntry, bool) {
+	e := tt.entries[hash&tt.mask].load()
+	if e.bound == boundNone || e.hash != hash {
+		return ttEntry{}, false
+	}
+	return e, true
+}
+
+// store records the result of searching the position with the given hash.
+//
+// The table is "depth preferred": an entry for a different position is only
+// replaced if it came from an earlier search or was searched to a lesser (or
+// equal) depth, since deeper searches are more expensive to repeat. Entries
+// for the same position are always replaced.
+func (tt *TranspositionTable) store(hash uint64, move Move, score int16, depth uint8, b bound) {
+	slot := &tt.entries[hash&tt.mask]
+	if e := slot.load(); e.bound != boundNone && e.hash != hash && e.age == tt.age && e.depth > depth {
+		return
+	}
+	slot.save(ttEntry{
+		hash:  hash,
+		move:  move,
+		score: score,
+		depth: depth,
+		bound: b,
+		age:   tt.age,
+	})
+}
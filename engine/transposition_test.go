@@ -0,0 +1,64 @@
+package engine_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/GeorgeBills/chess/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTranspositionTable(t *testing.T) {
+	tests := []struct {
+		megabytes int
+		expected  int
+	}{
+		{0, 1},
+		{1, 65_536},
+		{3, 131_072}, // rounded down to a power of two
+		{16, 1_048_576},
+	}
+	for _, tt := range tests {
+		table := engine.NewTranspositionTable(tt.megabytes)
+		assert.Equal(t, tt.expected, table.Len(), "%d megabytes", tt.megabytes)
+	}
+}
+
+func TestBestMoveToDepthTranspositionTable(t *testing.T) {
+	table := engine.NewTranspositionTable(1)
+	for _, tt := range bestMoveTests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := engine.NewBoardFromFEN(strings.NewReader(tt.fen))
+			require.NoError(t, err)
+			require.NotNil(t, b)
+			g := engine.NewGame(b)
+			g.SetTranspositionTable(table)
+
+			// search twice: once filling the table, and once hitting it
+			cold, coldScore := g.BestMoveToDepth(tt.depth, nil, nil)
+			assert.Equal(t, tt.expected, cold.SAN())
+			warm, warmScore := g.BestMoveToDepth(tt.depth, nil, nil)
+			assert.Equal(t, tt.expected, warm.SAN())
+			assert.Equal(t, coldScore, warmScore)
+
+			// search again with a cleared table
+			table.Clear()
+			cleared, _ := g.BestMoveToDepth(tt.depth, nil, nil)
+			assert.Equal(t, tt.expected, cleared.SAN())
+		})
+	}
+}
+
+func BenchmarkBestMoveToDepthTranspositionTable(b *testing.B) {
+	const depth = 4
+
+	g := engine.NewGame(engine.NewBoard())
+	table := engine.NewTranspositionTable(engine.DefaultTranspositionTableMegabytes)
+	g.SetTranspositionTable(table)
+
+	for i := 0; i < b.N; i++ {
+		table.Clear()
+		g.BestMoveToDepth(depth, nil, nil)
+	}
+}
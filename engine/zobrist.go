@@ -0,0 +1,121 @@
+package engine
+
+import (
+	"fmt"
+)
+
+// https://www.chessprogramming.org/Zobrist_Hashing
+
+// The Zobrist keys are laid out in a single flat table: 12 × 64 keys for each
+// piece on each square, 4 keys for the castling rights, 8 keys for the en
+// passant file and a final key for the side to move.
+const (
+	zobristOffsetPiece         = 0
+	zobristOffsetCastling      = zobristOffsetPiece + 12*64
+	zobristOffsetEnPassantFile = zobristOffsetCastling + 4
+	zobristOffsetToMove        = zobristOffsetEnPassantFile + 8
+	zobristNumKeys             = zobristOffsetToMove + 1
+)
+
+var (
+	zobristKeys [zobristNumKeys]uint64
+
+	// zobristCastling holds pre-combined keys for every combination of the
+	// castling bits in the board meta, indexed by meta >> 4.
+	zobristCastling [16]uint64
+)
+
+func init() {
+	// xorshift64* with a fixed seed, so that keys (and therefore hashes) are
+	// stable from run to run
+	// https://en.wikipedia.org/wiki/Xorshift#xorshift*
+	var x uint64 = 0x4C1D_2AB5_0F37_E9D3
+	for i := range zobristKeys {
+		x ^= x >> 12
+		x ^= x << 25
+		x ^= x >> 27
+		zobristKeys[i] = x * 0x2545_F491_4F6C_DD1D
+	}
+
+	for i := range zobristCastling {
+		var key uint64
+		meta := uint8(i << 4)
+		if meta&maskWhiteCastleKingside != 0 {
+			key ^= zobristKeys[zobristOffsetCastling+0]
+		}
+		if meta&maskWhiteCastleQueenside != 0 {
+			key ^= zobristKeys[zobristOffsetCastling+1]
+		}
+		if meta&maskBlackCastleKingside != 0 {
+			key ^= zobristKeys[zobristOffsetCastling+2]
+		}
+		if meta&maskBlackCastleQueenside != 0 {
+			key ^= zobristKeys[zobristOffsetCastling+3]
+		}
+		zobristCastling[i] = key
+	}
+}
+
+// zobristPiece returns the key for piece p on square sq.
+func zobristPiece(p Piece, sq uint8) uint64 {
+	var kind int
+	switch p {
+	case PieceBlackPawn:
+		kind = 0
+	case PieceWhitePawn:
+		kind = 1
+	case PieceBlackKnight:
+		kind = 2
+	case PieceWhiteKnight:
+		kind = 3
+	case PieceBlackBishop:
+		kind = 4
+	case PieceWhiteBishop:
+		kind = 5
+	case PieceBlackRook:
+		kind = 6
+	case PieceWhiteRook:
+		kind = 7
+	case PieceBlackQueen:
+		kind = 8
+	case PieceWhiteQueen:
+		kind = 9
+	case PieceBlackKing:
+		kind = 10
+	case PieceWhiteKing:
+		kind = 11
+	default:
+		panic(fmt.Errorf("invalid piece for zobrist key: %b", p))
+	}
+	return zobristKeys[zobristOffsetPiece+64*kind+int(sq)]
+}
+
+// zobristMeta returns the combined key for the castling rights and en passant
+// file recorded in meta.
+func zobristMeta(meta byte) uint64 {
+	key := zobristCastling[meta>>4]
+	if meta&maskCanEnPassant != 0 {
+		key ^= zobristKeys[zobristOffsetEnPassantFile+int(meta&maskEnPassantFile)]
+	}
+	return key
+}
+
+// Hash returns the Zobrist hash for the board. The hash is maintained
+// incrementally as moves are made and unmade, and identifies the position:
+// piece placement, side to move, castling rights and en passant file.
+func (b Board) Hash() uint64 { return b.hash }
+
+// ComputeHash calculates the Zobrist hash for the board from scratch. It should
+// always agree with Hash(), and is mostly useful for verifying that it does.
+func (b Board) ComputeHash() uint64 {
+	var hash uint64
+	for occupied := b.white | b.black; occupied != 0; {
+		sq, _ := popLSB(&occupied)
+		hash ^= zobristPiece(b.PieceAt(sq), sq)
+	}
+	hash ^= zobristMeta(b.meta)
+	if b.ToMove() == White {
+		hash ^= zobristKeys[zobristOffsetToMove]
+	}
+	return hash
+}
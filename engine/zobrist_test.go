@@ -0,0 +1,116 @@
+package engine_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/GeorgeBills/chess/engine"
+	"github.com/GeorgeBills/chess/uci"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// perftHash walks the move tree like perft, checking at every node that the
+// incrementally updated hash agrees with the hash calculated from scratch, and
+// that unmaking a move restores the previous hash.
+func perftHash(t *testing.T, g *engine.Game, depth uint8) uint64 {
+	require.Equal(t, g.ComputeHash(), g.Hash(), "incremental hash should match computed hash for %s", g.FEN())
+	if depth == 0 {
+		return 1
+	}
+	moves, _ := g.GenerateLegalMoves(nil)
+	var n uint64
+	for _, move := range moves {
+		before := g.Hash()
+		g.MakeMove(move)
+		n += perftHash(t, g, depth-1)
+		g.UnmakeMove()
+		require.Equal(t, before, g.Hash(), "hash should be restored after unmaking %s", move.SAN())
+	}
+	return n
+}
+
+func TestHashPerft(t *testing.T) {
+	tests := []struct {
+		name     string
+		fen      string
+		depth    uint8
+		expected uint64
+	}{
+		{
+			"initial",
+			"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+			4,
+			197_281,
+		},
+		{
+			"kiwipete", // https://www.chessprogramming.org/Perft_Results
+			"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 123",
+			3,
+			97_862,
+		},
+		{
+			"position 3", // https://www.chessprogramming.org/Perft_Results
+			"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 123",
+			4,
+			43_238,
+		},
+		{
+			"position 4", // https://www.chessprogramming.org/Perft_Results
+			"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 123",
+			3,
+			9_467,
+		},
+		{
+			"position 5", // https://www.chessprogramming.org/Perft_Results
+			"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+			3,
+			62_379,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := engine.NewBoardFromFEN(strings.NewReader(tt.fen))
+			require.NoError(t, err)
+			require.NotNil(t, b)
+			g := engine.NewGame(b)
+			n := perftHash(t, g, tt.depth)
+			assert.Equal(t, tt.expected, n)
+		})
+	}
+}
+
+func TestHashTransposition(t *testing.T) {
+	// the same position reached via different move orders has the same hash
+	play := func(ucins ...string) uint64 {
+		b := engine.NewBoard()
+		g := engine.NewGame(b)
+		for _, ucin := range ucins {
+			parsed, err := uci.ParseUCIN(ucin)
+			require.NoError(t, err)
+			move, err := b.HydrateMove(parsed)
+			require.NoError(t, err)
+			g.MakeMove(move)
+		}
+		return g.Hash()
+	}
+	assert.Equal(t, play("g1f3", "g8f6", "b1c3"), play("b1c3", "g8f6", "g1f3"))
+	assert.NotEqual(t, play("g1f3", "g8f6", "b1c3"), play("g1f3", "g8f6", "b1a3"))
+
+	// the same pieces with different castling rights, en passant or side to
+	// move all hash differently
+	fens := []string{
+		"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1",
+		"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1",
+		"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 1",
+		"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b Qkq - 0 1",
+		"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b - - 0 1",
+	}
+	seen := make(map[uint64]string, len(fens))
+	for _, fen := range fens {
+		b, err := engine.NewBoardFromFEN(strings.NewReader(fen))
+		require.NoError(t, err)
+		assert.NotContains(t, seen, b.Hash(), "hash for %s collides with %s", fen, seen[b.Hash()])
+		seen[b.Hash()] = fen
+	}
+}
@@ -0,0 +1,266 @@
+// Package epd implements reading and writing of positions in Extended Position
+// Description.
+//
+// EPD is documented as part of the PGN standard at
+// http://www.saremba.de/chessgml/standards/pgn/pgn-complete.htm#c16.2. An EPD
+// record is the first four fields of a FEN (the piece placement, side to
+// move, castling rights and en passant square) followed by any number of
+// operations, each an opcode followed by zero or more operands and terminated
+// by a semicolon:
+//
+//	2rr3k/pp3pp1/1nnqbN1p/3pN3/2pP4/2P3Q1/PPB4P/R4RK1 w - - bm Qg6; id "WAC.001";
+//
+// Test suites such as Win at Chess use EPD to give the best move ("bm") or
+// moves to avoid ("am") in each position.
+package epd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/GeorgeBills/chess/engine"
+)
+
+// https://www.chessprogramming.org/Extended_Position_Description
+
+// Operation is an EPD operation: an opcode and its operands.
+type Operation struct {
+	Opcode   string
+	Operands []string
+}
+
+// Position is a position read from EPD, along with its operations.
+type Position struct {
+	Board      *engine.Board
+	Operations []Operation
+}
+
+// Parse parses a single line of EPD. The half move clock and full move number
+// for the board are taken from the "hmvc" and "fmvn" operations if present, and
+// are otherwise 0 and 1.
+func Parse(line string) (*Position, error) {
+	fields, rest := splitFields(line, 4)
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("expecting 4 fields, found %d", len(fields))
+	}
+
+	ops, err := parseOperations(rest)
+	if err != nil {
+		return nil, err
+	}
+	p := &Position{Operations: ops}
+
+	hmvc, fmvn := "0", "1"
+	if operands := p.Operands("hmvc"); len(operands) == 1 {
+		hmvc = operands[0]
+	}
+	if operands := p.Operands("fmvn"); len(operands) == 1 {
+		fmvn = operands[0]
+	}
+
+	fen := strings.Join(append(fields, hmvc, fmvn), " ")
+	if p.Board, err = engine.NewBoardFromFEN(strings.NewReader(fen)); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// splitFields returns the first n white space separated fields of s, and the
+// rest of s following them.
+func splitFields(s string, n int) ([]string, string) {
+	var fields []string
+	for len(fields) < n {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			break
+		}
+		end := strings.IndexAny(s, " \t")
+		if end < 0 {
+			end = len(s)
+		}
+		fields = append(fields, s[:end])
+		s = s[end:]
+	}
+	return fields, s
+}
+
+// parseOperations parses semicolon terminated operations. Operands may be
+// quoted with double quotes, in which case they may contain spaces and
+// semicolons. The final semicolon may be omitted.
+func parseOperations(s string) ([]Operation, error) {
+	var ops []Operation
+	var tokens []string
+
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; {
+		case ch == ' ' || ch == '\t':
+			continue
+		case ch == ';':
+			if len(tokens) == 0 {
+				return nil, errors.New("empty operation")
+			}
+			ops = append(ops, Operation{Opcode: tokens[0], Operands: tokens[1:]})
+			tokens = nil
+		case ch == '"':
+			if len(tokens) == 0 {
+				return nil, errors.New("opcode can't be quoted")
+			}
+			end := strings.IndexByte(s[i+1:], '"')
+			if end < 0 {
+				return nil, errors.New("unterminated string operand")
+			}
+			tokens = append(tokens, s[i+1:i+1+end])
+			i += end + 1
+		default:
+			end := strings.IndexAny(s[i:], " \t;")
+			if end < 0 {
+				end = len(s) - i
+			}
+			tokens = append(tokens, s[i:i+end])
+			i += end - 1
+		}
+	}
+	if len(tokens) > 0 {
+		ops = append(ops, Operation{Opcode: tokens[0], Operands: tokens[1:]})
+	}
+
+	return ops, nil
+}
+
+// Operands returns the operands for the first operation with the given
+// opcode, or nil if there is no such operation.
+func (p *Position) Operands(opcode string) []string {
+	for _, op := range p.Operations {
+		if op.Opcode == opcode {
+			return op.Operands
+		}
+	}
+	return nil
+}
+
+// ID returns the operand of the "id" operation, or an empty string if there
+// isn't one.
+func (p *Position) ID() string {
+	if operands := p.Operands("id"); len(operands) > 0 {
+		return operands[0]
+	}
+	return ""
+}
+
+// Set sets the operands for the operation with the given opcode, replacing
+// any existing operation with that opcode or else adding a new one.
+func (p *Position) Set(opcode string, operands ...string) {
+	for i, op := range p.Operations {
+		if op.Opcode == opcode {
+			p.Operations[i].Operands = operands
+			return
+		}
+	}
+	p.Operations = append(p.Operations, Operation{Opcode: opcode, Operands: operands})
+}
+
+// String returns the position as a line of EPD. String operands (for the "id"
+// and comment opcodes, or that contain white space or semicolons) are quoted.
+func (p *Position) String() string {
+	var sb strings.Builder
+
+	fields := strings.Fields(p.Board.FEN())
+	sb.WriteString(strings.Join(fields[:4], " "))
+
+	for _, op := range p.Operations {
+		sb.WriteByte(' ')
+		sb.WriteString(op.Opcode)
+		for _, operand := range op.Operands {
+			sb.WriteByte(' ')
+			if isStringOpcode(op.Opcode) || operand == "" || strings.ContainsAny(operand, " \t;") {
+				sb.WriteByte('"')
+				sb.WriteString(operand)
+				sb.WriteByte('"')
+			} else {
+				sb.WriteString(operand)
+			}
+		}
+		sb.WriteByte(';')
+	}
+
+	return sb.String()
+}
+
+// isStringOpcode returns true if the operands for opcode are always strings:
+// the position's id, comments (c0...c9) and variation names (v0...v9).
+func isStringOpcode(opcode string) bool {
+	if opcode == "id" {
+		return true
+	}
+	return len(opcode) == 2 && (opcode[0] == 'c' || opcode[0] == 'v') && opcode[1] >= '0' && opcode[1] <= '9'
+}
+
+// Reader reads positions from EPD, one per line. Blank lines are skipped.
+type Reader struct {
+	s    *bufio.Scanner
+	line int
+}
+
+// NewReader returns a new Reader that reads from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{s: bufio.NewScanner(r)}
+}
+
+// Read reads the next position, returning io.EOF if there are no more. Errors
+// for lines that can't be parsed include the line number, and the next call
+// to Read continues with the following line.
+func (r *Reader) Read() (*Position, error) {
+	for r.s.Scan() {
+		r.line++
+		line := strings.TrimSpace(r.s.Text())
+		if line == "" {
+			continue
+		}
+		p, err := Parse(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", r.line, err)
+		}
+		return p, nil
+	}
+	if err := r.s.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// Check returns true if m passes the position's best move test: m must be one
+// of the best moves ("bm"), if given, and none of the moves to avoid ("am"), if
+// given. An error is returned if the operands aren't legal moves in SAN.
+func (p *Position) Check(m engine.Move) (bool, error) {
+	if bm := p.Operands("bm"); len(bm) > 0 {
+		found, err := p.contains(bm, m)
+		if err != nil || !found {
+			return false, err
+		}
+	}
+	if am := p.Operands("am"); len(am) > 0 {
+		found, err := p.contains(am, m)
+		if err != nil || found {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// contains returns true if m is one of the moves in sans.
+func (p *Position) contains(sans []string, m engine.Move) (bool, error) {
+	for _, san := range sans {
+		parsed, err := p.Board.ParseSAN(san)
+		if err != nil {
+			return false, err
+		}
+		if parsed == m {
+			return true, nil
+		}
+	}
+	return false, nil
+}
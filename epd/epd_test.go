@@ -0,0 +1,182 @@
+package epd_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/GeorgeBills/chess/engine"
+	"github.com/GeorgeBills/chess/epd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		epd      string
+		fen      string
+		ops      []epd.Operation
+		expected string // EPD as written, if it differs from the input
+	}{
+		{
+			"best move and id",
+			`2rr3k/pp3pp1/1nnqbN1p/3pN3/2pP4/2P3Q1/PPB4P/R4RK1 w - - bm Qg6; id "WAC.001";`,
+			"2rr3k/pp3pp1/1nnqbN1p/3pN3/2pP4/2P3Q1/PPB4P/R4RK1 w - - 0 1",
+			[]epd.Operation{{"bm", []string{"Qg6"}}, {"id", []string{"WAC.001"}}},
+			"",
+		},
+		{
+			"no operations",
+			"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -",
+			engine.InitialBoardFEN,
+			nil,
+			"",
+		},
+		{
+			"several operands",
+			`r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - bm Bb5 Bc4; am Ng5;`,
+			"r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 0 1",
+			[]epd.Operation{{"bm", []string{"Bb5", "Bc4"}}, {"am", []string{"Ng5"}}},
+			"",
+		},
+		{
+			"analysis",
+			`rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 acd 12; ce 25; pv e5 Nf3; c0 "a comment; with a semicolon";`,
+			"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1",
+			[]epd.Operation{
+				{"acd", []string{"12"}},
+				{"ce", []string{"25"}},
+				{"pv", []string{"e5", "Nf3"}},
+				{"c0", []string{"a comment; with a semicolon"}},
+			},
+			"",
+		},
+		{
+			"move counters",
+			"4k3/8/8/8/8/8/8/4K3 w - - hmvc 12; fmvn 40;",
+			"4k3/8/8/8/8/8/8/4K3 w - - 12 40",
+			[]epd.Operation{{"hmvc", []string{"12"}}, {"fmvn", []string{"40"}}},
+			"",
+		},
+		{
+			"operation without operands and final semicolon",
+			"4k3/8/8/8/8/8/8/4K3 w - -   noop ;  id  x",
+			"4k3/8/8/8/8/8/8/4K3 w - - 0 1",
+			[]epd.Operation{{"noop", []string{}}, {"id", []string{"x"}}},
+			`4k3/8/8/8/8/8/8/4K3 w - - noop; id "x";`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := epd.Parse(tt.epd)
+			require.NoError(t, err)
+			assert.Equal(t, tt.fen, p.Board.FEN())
+			assert.Equal(t, tt.ops, p.Operations)
+
+			expected := tt.expected
+			if expected == "" {
+				expected = tt.epd
+			}
+			assert.Equal(t, expected, p.String())
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		epd  string
+	}{
+		{"empty", ""},
+		{"too few fields", "4k3/8/8/8/8/8/8/4K3 w -"},
+		{"invalid position", "4k3/8/8/8/8/8/8/4KK2 w - - bm Kd1;"},
+		{"empty operation", "4k3/8/8/8/8/8/8/4K3 w - - bm Kd1;;"},
+		{"unterminated string", `4k3/8/8/8/8/8/8/4K3 w - - id "oops;`},
+		{"quoted opcode", `4k3/8/8/8/8/8/8/4K3 w - - "id" x;`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := epd.Parse(tt.epd)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSet(t *testing.T) {
+	p, err := epd.Parse(`4k3/8/8/8/8/8/8/4K3 w - - id "test"; acd 1;`)
+	require.NoError(t, err)
+	p.Set("acd", "10")
+	p.Set("pv", "Kd2", "Kd7")
+	assert.Equal(t, `4k3/8/8/8/8/8/8/4K3 w - - id "test"; acd 10; pv Kd2 Kd7;`, p.String())
+}
+
+func TestCheck(t *testing.T) {
+	p, err := epd.Parse("r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - bm Bb5 Bc4; am Ng5;")
+	require.NoError(t, err)
+
+	for san, expected := range map[string]bool{"Bb5": true, "Bc4": true, "d4": false, "Ng5": false} {
+		m, err := p.Board.ParseSAN(san)
+		require.NoError(t, err)
+		ok, err := p.Check(m)
+		require.NoError(t, err)
+		assert.Equal(t, expected, ok, san)
+	}
+
+	p, err = epd.Parse("r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - am Ng5;")
+	require.NoError(t, err)
+	m, err := p.Board.ParseSAN("d4")
+	require.NoError(t, err)
+	ok, err := p.Check(m)
+	require.NoError(t, err)
+	assert.True(t, ok, "any move but the move to avoid should pass")
+
+	p, err = epd.Parse("r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - bm Qh8;")
+	require.NoError(t, err)
+	_, err = p.Check(m)
+	assert.True(t, errors.Is(err, engine.ErrIllegalMove), "expected an illegal move error, got %v", err)
+}
+
+func TestReader(t *testing.T) {
+	f, err := os.Open("testdata/wac.epd")
+	require.NoError(t, err)
+	defer f.Close()
+
+	r := epd.NewReader(f)
+	var ids []string
+	for {
+		p, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		ids = append(ids, p.ID())
+
+		for _, san := range p.Operands("bm") {
+			_, err := p.Board.ParseSAN(san)
+			assert.NoError(t, err, "best move for %s should be legal", p.ID())
+		}
+	}
+	assert.Equal(t, []string{"WAC.001", "WAC.002", "WAC.003", "WAC.004", "WAC.005"}, ids)
+}
+
+func TestReaderErrors(t *testing.T) {
+	r := epd.NewReader(strings.NewReader("4k3/8/8/8/8/8/8/4K3 w - - id \"1\";\n\nnonsense\n4k3/8/8/8/8/8/8/4K3 b - - id \"2\";\n"))
+
+	p, err := r.Read()
+	require.NoError(t, err)
+	assert.Equal(t, "1", p.ID())
+
+	_, err = r.Read()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 3")
+
+	p, err = r.Read()
+	require.NoError(t, err)
+	assert.Equal(t, "2", p.ID())
+
+	_, err = r.Read()
+	assert.Equal(t, io.EOF, err)
+}
@@ -0,0 +1,26 @@
+// Package pgn implements reading and writing of games in Portable Game
+// Notation.
+//
+// PGN is canonically documented at
+// http://www.saremba.de/chessgml/standards/pgn/pgn-complete.htm.
+//
+// A PGN file holds any number of games, each of which is a tag pair section
+// followed by movetext:
+//
+//	[Event "F/S Return Match"]
+//	[Site "Belgrade, Serbia JUG"]
+//	[Date "1992.11.04"]
+//	[Round "29"]
+//	[White "Fischer, Robert J."]
+//	[Black "Spassky, Boris V."]
+//	[Result "1/2-1/2"]
+//
+//	1. e4 e5 2. Nf3 Nc6 3. Bb5 {This opening is called the Ruy Lopez.} 3...
+//	a6 4. Ba4 Nf6 5. O-O Be7 6. Re1 b5 7. Bb3 d6 8. c3 O-O 9. h3 Nb8 $6
+//	(9... h6 10. d4) 10. d4 Nbd7 1/2-1/2
+//
+// Movetext is a sequence of moves in Standard Algebraic Notation, optionally
+// annotated with comments ({...} or ; to the end of the line), numeric
+// annotation glyphs ($1, $2, ...) and recursive annotation variations ((...)),
+// and terminated by the result of the game.
+package pgn
@@ -0,0 +1,320 @@
+package pgn
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/GeorgeBills/chess/engine"
+)
+
+// Results that terminate a game's movetext.
+const (
+	ResultWhiteWins = "1-0"
+	ResultBlackWins = "0-1"
+	ResultDraw      = "1/2-1/2"
+	ResultUnknown   = "*"
+)
+
+// Tag is a tag pair, giving information about the game (e.g. the players, the
+// event or the date).
+type Tag struct {
+	Name, Value string
+}
+
+// Move is a move in the movetext, with any annotations that follow it.
+type Move struct {
+	engine.Move
+
+	// SAN is the move in Standard Algebraic Notation.
+	SAN string
+
+	// NAGs are the numeric annotation glyphs for the move, e.g. 1 for a good
+	// move ("!") or 6 for a dubious move ("?!").
+	NAGs []int
+
+	// Comments are the comments that follow the move.
+	Comments []string
+
+	// Variations are alternatives to the move.
+	Variations []Variation
+}
+
+// Variation is a sequence of moves, along with any comments that precede the
+// first of them.
+type Variation struct {
+	Comments []string
+	Moves    []Move
+}
+
+// Game is a game read from PGN.
+type Game struct {
+	// Tags are the tag pairs for the game, in the order they were read.
+	Tags []Tag
+
+	// Variation is the main line of the game.
+	Variation
+
+	// Result is the game termination marker: one of "1-0", "0-1", "1/2-1/2" or
+	// "*".
+	Result string
+
+	// Position is the position at the end of the main line, with the moves
+	// of the main line in its history.
+	Position *engine.Game
+}
+
+// Tag returns the value for the tag with the given name, or an empty string if
+// the game has no such tag.
+func (g *Game) Tag(name string) string {
+	for _, t := range g.Tags {
+		if t.Name == name {
+			return t.Value
+		}
+	}
+	return ""
+}
+
+// Reader reads games from PGN. Games are read one at a time, and only as much
+// of the underlying reader is consumed as is needed for each game.
+type Reader struct {
+	s *scanner
+}
+
+// NewReader returns a new Reader that reads from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{s: newScanner(r)}
+}
+
+// Read reads the next game. The moves are replayed from the position given by
+// the FEN tag, if present, or else from the standard starting position.
+//
+// Read returns io.EOF if there are no more games. If the game can't be read
+// then the error is a *SyntaxError giving the position of the problem, and
+// the rest of the game is skipped so that the next call to Read can continue
+// with the following game.
+func (r *Reader) Read() (*Game, error) {
+	t, err := r.s.next()
+	if err != nil {
+		return nil, err
+	}
+	if t.kind == tokenEOF {
+		return nil, io.EOF
+	}
+
+	g, err := r.readGame(t)
+	if err != nil {
+		var serr *SyntaxError
+		if !errors.As(err, &serr) {
+			return nil, err
+		}
+		if err := r.skipGame(); err != nil {
+			return nil, err
+		}
+		return nil, serr
+	}
+	return g, nil
+}
+
+func (r *Reader) readGame(t token) (*Game, error) {
+	g := &Game{}
+
+	var err error
+	for t.kind == tokenOpenBracket {
+		var tag Tag
+		if tag, err = r.readTag(t); err != nil {
+			return nil, err
+		}
+		g.Tags = append(g.Tags, tag)
+		if t, err = r.s.next(); err != nil {
+			return nil, err
+		}
+	}
+
+	b := engine.NewBoard()
+	if fen := g.Tag("FEN"); fen != "" {
+		if b, err = engine.NewBoardFromFEN(strings.NewReader(fen)); err != nil {
+			return nil, &SyntaxError{Line: t.line, Column: t.column, Err: err}
+		}
+	}
+	g.Position = engine.NewGame(b)
+
+	if g.Result, err = r.readVariation(t, g.Position, &g.Variation, 0); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// readTag reads a tag pair, t being the opening bracket.
+func (r *Reader) readTag(t token) (Tag, error) {
+	name, err := r.expect(tokenSymbol, "tag name")
+	if err != nil {
+		return Tag{}, err
+	}
+	value, err := r.expect(tokenString, "tag value")
+	if err != nil {
+		return Tag{}, err
+	}
+	if _, err = r.expect(tokenCloseBracket, "']'"); err != nil {
+		return Tag{}, err
+	}
+	return Tag{Name: name.value, Value: value.value}, nil
+}
+
+// expect returns the next token, or an error if it isn't of the given kind.
+func (r *Reader) expect(kind tokenKind, what string) (token, error) {
+	t, err := r.s.next()
+	if err != nil {
+		return token{}, err
+	}
+	if t.kind != kind {
+		return token{}, unexpected(t, what)
+	}
+	return t, nil
+}
+
+// readVariation reads moves into v, starting with t and making each move on
+// pos, until the end of the variation: a closing parenthesis for a nested
+// variation (depth > 0), or the result for the main line. The result is
+// returned.
+func (r *Reader) readVariation(t token, pos *engine.Game, v *Variation, depth int) (string, error) {
+	var err error
+	for ; ; t, err = r.s.next() {
+		if err != nil {
+			return "", err
+		}
+
+		var last *Move
+		if len(v.Moves) > 0 {
+			last = &v.Moves[len(v.Moves)-1]
+		}
+
+		switch t.kind {
+		case tokenSymbol:
+			switch {
+			case isResult(t.value):
+				if depth > 0 {
+					return "", unexpected(t, "')'")
+				}
+				return t.value, nil
+			case isMoveNumber(t.value):
+				continue
+			}
+			m, err := pos.ParseSAN(t.value)
+			if err != nil {
+				return "", &SyntaxError{Line: t.line, Column: t.column, Err: err}
+			}
+			v.Moves = append(v.Moves, Move{Move: m, SAN: pos.SAN(m)})
+			pos.MakeMove(m)
+
+		case tokenPeriod:
+			continue
+
+		case tokenAsterisk:
+			if depth > 0 {
+				return "", unexpected(t, "')'")
+			}
+			return ResultUnknown, nil
+
+		case tokenNAG:
+			if last == nil {
+				return "", unexpected(t, "move")
+			}
+			nag, err := strconv.Atoi(t.value)
+			if err != nil {
+				return "", &SyntaxError{Line: t.line, Column: t.column, Err: err}
+			}
+			last.NAGs = append(last.NAGs, nag)
+
+		case tokenComment:
+			if last == nil {
+				v.Comments = append(v.Comments, t.value)
+			} else {
+				last.Comments = append(last.Comments, t.value)
+			}
+
+		case tokenOpenParen:
+			// a variation is an alternative to the last move, so is played
+			// from the position before it
+			if last == nil {
+				return "", unexpected(t, "move")
+			}
+			next, err := r.s.next()
+			if err != nil {
+				return "", err
+			}
+			pos.UnmakeMove()
+			var variation Variation
+			if _, err := r.readVariation(next, pos, &variation, depth+1); err != nil {
+				return "", err
+			}
+			for range variation.Moves {
+				pos.UnmakeMove()
+			}
+			pos.MakeMove(last.Move)
+			last.Variations = append(last.Variations, variation)
+
+		case tokenCloseParen:
+			if depth == 0 {
+				return "", unexpected(t, "move or result")
+			}
+			return "", nil
+
+		case tokenOpenBracket:
+			// the next game has started without this one being terminated;
+			// leave the bracket to be read with the next game's tags
+			r.s.push(t)
+			return "", unexpected(t, "result")
+
+		default:
+			return "", unexpected(t, "move")
+		}
+	}
+}
+
+// skipGame skips to the end of the current game, which is either its result or
+// the start of the next game's tags.
+func (r *Reader) skipGame() error {
+	for {
+		t, err := r.s.next()
+		if err != nil {
+			return err
+		}
+		switch {
+		case t.kind == tokenEOF,
+			t.kind == tokenAsterisk,
+			t.kind == tokenSymbol && isResult(t.value):
+			return nil
+		case t.kind == tokenOpenBracket:
+			r.s.push(t)
+			return nil
+		}
+	}
+}
+
+// unexpected returns a SyntaxError for the unexpected token t.
+func unexpected(t token, expecting string) error {
+	if t.kind == tokenEOF {
+		return errorf(t.line, t.column, "unexpected end of input, expecting %s", expecting)
+	}
+	return errorf(t.line, t.column, "unexpected %q, expecting %s", t.value, expecting)
+}
+
+func isResult(s string) bool {
+	switch s {
+	case ResultWhiteWins, ResultBlackWins, ResultDraw:
+		return true
+	default:
+		return false
+	}
+}
+
+func isMoveNumber(s string) bool {
+	for _, ch := range s {
+		if ch < '0' || ch > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,141 @@
+package pgn_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/GeorgeBills/chess/engine"
+	"github.com/GeorgeBills/chess/pgn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sans returns the SAN for each of the moves.
+func sans(moves []pgn.Move) []string {
+	var s []string
+	for _, m := range moves {
+		s = append(s, m.SAN)
+	}
+	return s
+}
+
+func TestReader(t *testing.T) {
+	f, err := os.Open("testdata/games.pgn")
+	require.NoError(t, err)
+	defer f.Close()
+
+	r := pgn.NewReader(f)
+
+	// Fischer vs Spassky: comments, NAGs and nested variations
+	g, err := r.Read()
+	require.NoError(t, err)
+	assert.Len(t, g.Tags, 7)
+	assert.Equal(t, "Fischer, Robert J.", g.Tag("White"))
+	assert.Equal(t, "", g.Tag("FEN"))
+	assert.Equal(t, pgn.ResultDraw, g.Result)
+	assert.Equal(t, []string{
+		"e4", "e5", "Nf3", "Nc6", "Bb5", "a6", "Ba4", "Nf6", "O-O", "Be7",
+		"Re1", "b5", "Bb3", "d6", "c3", "O-O", "h3", "Nb8", "d4", "Nbd7",
+	}, sans(g.Moves))
+	assert.Equal(t, []string{"This opening is called the Ruy Lopez."}, g.Moves[4].Comments)
+
+	nb8 := g.Moves[17]
+	assert.Equal(t, []int{6}, nb8.NAGs)
+	require.Len(t, nb8.Variations, 1)
+	assert.Equal(t, []string{"h6", "d4", "Re8"}, sans(nb8.Variations[0].Moves))
+	d4 := nb8.Variations[0].Moves[1]
+	require.Len(t, d4.Variations, 1)
+	assert.Equal(t, []string{"d3"}, sans(d4.Variations[0].Moves))
+	assert.Equal(t, []int{5}, d4.Variations[0].Moves[0].NAGs)
+	assert.Equal(t, "r1bq1rk1/2pnbppp/p2p1n2/1p2p3/3PP3/1BP2N1P/PP3PP1/RNBQR1K1 w - - 1 11", g.Position.FEN())
+
+	// the Opera Game: a comment before the first move, and checkmate
+	g, err = r.Read()
+	require.NoError(t, err)
+	assert.Equal(t, "Paul Morphy", g.Tag("White"))
+	assert.Equal(t, []string{"The Opera Game."}, g.Comments)
+	assert.Len(t, g.Moves, 33)
+	assert.Equal(t, "Rd8#", g.Moves[32].SAN)
+	assert.Equal(t, pgn.ResultWhiteWins, g.Result)
+	assert.Equal(t, engine.TerminationCheckmate, g.Position.Outcome().Termination)
+
+	// a game from a FEN, with a rest of line comment
+	g, err = r.Read()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"e4", "Kd7", "Kd2", "Ke6"}, sans(g.Moves))
+	assert.Equal(t, []string{"the king heads for the pawn"}, g.Moves[1].Comments)
+	assert.Equal(t, pgn.ResultUnknown, g.Result)
+	assert.Equal(t, "8/8/4k3/8/4P3/8/3K4/8 w - - 3 3", g.Position.FEN())
+
+	_, err = r.Read()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestReaderErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		pgn          string
+		line, column int
+		err          error
+	}{
+		{"illegal move", "1. e4 e5 2. Ke3 *", 1, 13, engine.ErrIllegalMove},
+		{"ambiguous move", "[FEN \"4k3/8/8/8/8/8/8/1N2KN2 w - - 0 1\"]\n\n1. Nd2 *", 3, 4, engine.ErrAmbiguousSAN},
+		{"malformed move", "1. e4 e5\n2. Nx *", 2, 4, engine.ErrMalformedSAN},
+		{"unterminated comment", "1. e4 {oops", 1, 7, nil},
+		{"unterminated string", "[Event \"oops]\n", 1, 8, nil},
+		{"missing tag value", "[Event]\n", 1, 7, nil},
+		{"unclosed variation", "1. e4 (1. d4 *", 1, 14, nil},
+		{"unopened variation", "1. e4 ) *", 1, 7, nil},
+		{"variation before a move", "( 1. e4 ) *", 1, 1, nil},
+		{"missing result", "1. e4 e5", 1, 9, nil},
+		{"invalid FEN", "[FEN \"nonsense\"]\n1. e4 *", 2, 1, nil},
+		{"unexpected character", "1. e4 & *", 1, 7, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := pgn.NewReader(strings.NewReader(tt.pgn))
+			_, err := r.Read()
+			var serr *pgn.SyntaxError
+			require.True(t, errors.As(err, &serr), "expected a syntax error, got %v", err)
+			assert.Equal(t, tt.line, serr.Line, "line")
+			assert.Equal(t, tt.column, serr.Column, "column")
+			if tt.err != nil {
+				assert.True(t, errors.Is(err, tt.err), "expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestReaderContinuesAfterError(t *testing.T) {
+	const games = `[Event "Bad"]
+
+1. e4 e5 2. Ke3 Nc6 3. Nf3 1-0
+
+[Event "Unterminated"]
+
+1. d4 d5
+
+[Event "Good"]
+
+1. c4 *
+`
+	r := pgn.NewReader(strings.NewReader(games))
+
+	_, err := r.Read()
+	assert.True(t, errors.Is(err, engine.ErrIllegalMove))
+
+	_, err = r.Read()
+	var serr *pgn.SyntaxError
+	assert.True(t, errors.As(err, &serr))
+
+	g, err := r.Read()
+	require.NoError(t, err)
+	assert.Equal(t, "Good", g.Tag("Event"))
+	assert.Equal(t, []string{"c4"}, sans(g.Moves))
+
+	_, err = r.Read()
+	assert.Equal(t, io.EOF, err)
+}
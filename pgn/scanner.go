@@ -0,0 +1,244 @@
+package pgn
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// tokenKind identifies the kind of a token in PGN.
+type tokenKind uint8
+
+const (
+	tokenEOF          tokenKind = iota // end of input
+	tokenSymbol                        // moves, move numbers, results and tag names
+	tokenString                        // quoted tag values
+	tokenPeriod                        // "." following a move number
+	tokenAsterisk                      // "*", an unknown or ongoing result
+	tokenOpenBracket                   // "[" starting a tag pair
+	tokenCloseBracket                  // "]" ending a tag pair
+	tokenOpenParen                     // "(" starting a variation
+	tokenCloseParen                    // ")" ending a variation
+	tokenComment                       // "{...}" or ";..." to the end of the line
+	tokenNAG                           // "$" followed by a numeric annotation glyph
+)
+
+// token is a single token in PGN, along with its position in the input.
+type token struct {
+	kind         tokenKind
+	value        string
+	line, column int
+}
+
+// SyntaxError is returned for PGN that can't be read, and records the position
+// in the input at which the error was found.
+type SyntaxError struct {
+	Line, Column int // 1-indexed
+	Err          error
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %v", e.Line, e.Column, e.Err)
+}
+
+func (e *SyntaxError) Unwrap() error { return e.Err }
+
+// scanner splits PGN into tokens, reading only as much of the input as is
+// needed for the next token.
+type scanner struct {
+	r *bufio.Reader
+
+	// line and column are the position of the next rune to be read.
+	// prevLine and prevColumn are the position of the last rune read, so
+	// that it can be unread.
+	line, column         int
+	prevLine, prevColumn int
+
+	// unread holds a token to be returned by the next call to next.
+	unread *token
+}
+
+func newScanner(r io.Reader) *scanner {
+	return &scanner{r: bufio.NewReader(r), line: 1, column: 1}
+}
+
+// errorf returns a SyntaxError at the given position.
+func errorf(line, column int, format string, a ...interface{}) error {
+	return &SyntaxError{Line: line, Column: column, Err: fmt.Errorf(format, a...)}
+}
+
+func (s *scanner) readRune() (rune, error) {
+	ch, _, err := s.r.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	s.prevLine, s.prevColumn = s.line, s.column
+	if ch == '\n' {
+		s.line++
+		s.column = 1
+	} else {
+		s.column++
+	}
+	return ch, nil
+}
+
+func (s *scanner) unreadRune() {
+	_ = s.r.UnreadRune() // can't fail immediately after a successful read
+	s.line, s.column = s.prevLine, s.prevColumn
+}
+
+// push returns t from the next call to next.
+func (s *scanner) push(t token) {
+	s.unread = &t
+}
+
+// next returns the next token from the input. A token of kind tokenEOF is
+// returned at the end of the input.
+func (s *scanner) next() (token, error) {
+	if s.unread != nil {
+		t := *s.unread
+		s.unread = nil
+		return t, nil
+	}
+
+	for {
+		line, column := s.line, s.column
+		ch, err := s.readRune()
+		if errors.Is(err, io.EOF) {
+			return token{kind: tokenEOF, line: line, column: column}, nil
+		}
+		if err != nil {
+			return token{}, err
+		}
+
+		t := token{line: line, column: column, value: string(ch)}
+		switch {
+		case unicode.IsSpace(ch), ch == '\uFEFF': // byte order mark
+			continue
+		case ch == '%' && column == 1:
+			// escape mechanism: the rest of the line is ignored
+			if _, err := s.readUntil('\n'); err != nil && !errors.Is(err, io.EOF) {
+				return token{}, err
+			}
+			continue
+		case ch == '.':
+			t.kind = tokenPeriod
+		case ch == '*':
+			t.kind = tokenAsterisk
+		case ch == '[':
+			t.kind = tokenOpenBracket
+		case ch == ']':
+			t.kind = tokenCloseBracket
+		case ch == '(':
+			t.kind = tokenOpenParen
+		case ch == ')':
+			t.kind = tokenCloseParen
+		case ch == '{':
+			t.kind = tokenComment
+			t.value, err = s.readUntil('}')
+			if errors.Is(err, io.EOF) {
+				return token{}, errorf(line, column, "unterminated comment")
+			}
+			// comments may be wrapped over several lines
+			t.value = strings.Join(strings.Fields(t.value), " ")
+		case ch == ';':
+			t.kind = tokenComment
+			t.value, err = s.readUntil('\n')
+			if errors.Is(err, io.EOF) {
+				err = nil // a comment on the last line needn't end in a newline
+			}
+		case ch == '"':
+			t.kind = tokenString
+			t.value, err = s.readString()
+			if errors.Is(err, io.EOF) {
+				return token{}, errorf(line, column, "unterminated string")
+			}
+		case ch == '$':
+			t.kind = tokenNAG
+			t.value, err = s.readWhile(unicode.IsDigit)
+			if err == nil && t.value == "" {
+				return token{}, errorf(line, column, "expecting digits after '$'")
+			}
+		case isSymbolStart(ch):
+			s.unreadRune()
+			t.kind = tokenSymbol
+			t.value, err = s.readWhile(isSymbolContinuation)
+		default:
+			return token{}, errorf(line, column, "unexpected %q", ch)
+		}
+		if err != nil {
+			return token{}, err
+		}
+		return t, nil
+	}
+}
+
+// readUntil reads up to and including delim, returning what was read before it
+// with any leading and trailing white space removed.
+func (s *scanner) readUntil(delim rune) (string, error) {
+	var sb strings.Builder
+	for {
+		ch, err := s.readRune()
+		if err != nil {
+			return strings.TrimSpace(sb.String()), err
+		}
+		if ch == delim {
+			return strings.TrimSpace(sb.String()), nil
+		}
+		sb.WriteRune(ch)
+	}
+}
+
+// readWhile reads for as long as f returns true, returning what was read.
+func (s *scanner) readWhile(f func(rune) bool) (string, error) {
+	var sb strings.Builder
+	for {
+		ch, err := s.readRune()
+		if errors.Is(err, io.EOF) {
+			return sb.String(), nil
+		}
+		if err != nil {
+			return sb.String(), err
+		}
+		if !f(ch) {
+			s.unreadRune()
+			return sb.String(), nil
+		}
+		sb.WriteRune(ch)
+	}
+}
+
+// readString reads the rest of a quoted string, whose opening quote has already
+// been read, returning the unescaped contents.
+func (s *scanner) readString() (string, error) {
+	var sb strings.Builder
+	for {
+		ch, err := s.readRune()
+		if err != nil {
+			return sb.String(), err
+		}
+		switch ch {
+		case '"':
+			return sb.String(), nil
+		case '\\':
+			if ch, err = s.readRune(); err != nil {
+				return sb.String(), err
+			}
+		}
+		sb.WriteRune(ch)
+	}
+}
+
+func isSymbolStart(ch rune) bool {
+	return ch < unicode.MaxASCII && (unicode.IsLetter(ch) || unicode.IsDigit(ch))
+}
+
+// isSymbolContinuation returns true if ch may continue a symbol. As well as the
+// characters allowed by the standard, "!" and "?" are allowed, since move
+// suffix annotations (e.g. "e4!?") are common in the wild.
+func isSymbolContinuation(ch rune) bool {
+	return isSymbolStart(ch) || strings.ContainsRune("_+#=:-/!?", ch)
+}
@@ -0,0 +1,242 @@
+package pgn
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/GeorgeBills/chess/engine"
+)
+
+// maxLineLength is the longest line of movetext that Writer will write.
+const maxLineLength = 80
+
+// sevenTagRoster are the tags that every game must have, in the order they must
+// be written, along with the values to use if they're unknown.
+var sevenTagRoster = []Tag{
+	{"Event", "?"},
+	{"Site", "?"},
+	{"Date", "????.??.??"},
+	{"Round", "?"},
+	{"White", "?"},
+	{"Black", "?"},
+	{"Result", ResultUnknown},
+}
+
+// NewGame returns a game with the moves that have been made in pos. If pos
+// didn't start from the standard starting position then the game has SetUp
+// and FEN tags giving the position it did start from. The result is set if the
+// game is over.
+//
+// The moves in pos are unmade and remade to find the starting position and
+// the SAN for each move, so pos must not be used concurrently.
+func NewGame(pos *engine.Game) *Game {
+	history := pos.History()
+	for range history {
+		pos.UnmakeMove()
+	}
+
+	g := &Game{Position: pos}
+	if fen := pos.FEN(); fen != engine.InitialBoardFEN {
+		g.Tags = append(g.Tags, Tag{"SetUp", "1"}, Tag{"FEN", fen})
+	}
+
+	for _, m := range history {
+		g.Moves = append(g.Moves, Move{Move: m, SAN: pos.SAN(m)})
+		pos.MakeMove(m)
+	}
+	g.Result = pos.Outcome().Result()
+
+	return g
+}
+
+// Writer writes games as PGN, in the export format.
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter returns a new Writer that writes to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write writes the game g, followed by a blank line.
+//
+// The seven tag roster is written first, with "?" for any tags that g doesn't
+// have, followed by the rest of the tags in order. The Result tag always
+// matches g.Result. The moves are replayed from the position given by the FEN
+// tag, if present, or else from the standard starting position, and are
+// written in SAN with move numbers, comments, NAGs and variations. Lines of
+// movetext are wrapped so they're at most 80 characters long.
+func (w *Writer) Write(g *Game) error {
+	result := g.Result
+	if result == "" {
+		result = ResultUnknown
+	}
+
+	var sb strings.Builder
+
+	for _, t := range sevenTagRoster {
+		value := g.Tag(t.Name)
+		switch {
+		case t.Name == "Result":
+			value = result
+		case value == "":
+			value = t.Value
+		}
+		writeTag(&sb, Tag{t.Name, value})
+	}
+	for _, t := range g.Tags {
+		if !isSevenTagRoster(t.Name) {
+			writeTag(&sb, t)
+		}
+	}
+	sb.WriteByte('\n')
+
+	b := engine.NewBoard()
+	if fen := g.Tag("FEN"); fen != "" {
+		var err error
+		if b, err = engine.NewBoardFromFEN(strings.NewReader(fen)); err != nil {
+			return err
+		}
+	}
+
+	mt := &movetext{pos: engine.NewGame(b)}
+	mt.variation(g.Variation)
+	mt.add(result)
+	mt.wrap(&sb)
+	sb.WriteString("\n\n")
+
+	_, err := io.WriteString(w.w, sb.String())
+	return err
+}
+
+func writeTag(sb *strings.Builder, t Tag) {
+	sb.WriteByte('[')
+	sb.WriteString(t.Name)
+	sb.WriteString(` "`)
+	for _, ch := range t.Value {
+		if ch == '"' || ch == '\\' {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(ch)
+	}
+	sb.WriteString("\"]\n")
+}
+
+func isSevenTagRoster(name string) bool {
+	for _, t := range sevenTagRoster {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// movetext builds up the tokens of a game's movetext, replaying the moves on
+// pos to find their SAN and move numbers.
+type movetext struct {
+	pos    *engine.Game
+	tokens []string
+
+	// prefix is prepended to the next token added, to open a variation.
+	prefix string
+
+	// number is true if the next black move must be preceded by its move
+	// number, because it doesn't directly follow the white move.
+	number bool
+}
+
+// add adds a token.
+func (mt *movetext) add(token string) {
+	mt.tokens = append(mt.tokens, mt.prefix+token)
+	mt.prefix = ""
+}
+
+// variation adds the moves of v, and their annotations, leaving pos at the end
+// of the variation.
+func (mt *movetext) variation(v Variation) {
+	mt.comments(v.Comments)
+	mt.number = true
+	for _, m := range v.Moves {
+		mt.move(m)
+		for _, nag := range m.NAGs {
+			mt.add("$" + strconv.Itoa(nag))
+		}
+		mt.comments(m.Comments)
+		if len(m.Comments) > 0 {
+			mt.number = true
+		}
+
+		if len(m.Variations) > 0 {
+			// variations are alternatives to the move just made
+			mt.pos.UnmakeMove()
+			for _, v := range m.Variations {
+				if len(v.Moves) == 0 && len(v.Comments) == 0 {
+					continue
+				}
+				mt.prefix = "("
+				mt.variation(v)
+				for range v.Moves {
+					mt.pos.UnmakeMove()
+				}
+				mt.tokens[len(mt.tokens)-1] += ")"
+			}
+			mt.pos.MakeMove(m.Move)
+			mt.number = true
+		}
+	}
+}
+
+// move adds the move m, preceded by its move number if needed, and makes it.
+// The move number and move are added as a single token, so that they're never
+// split across lines.
+func (mt *movetext) move(m Move) {
+	var number string
+	n := strconv.Itoa(mt.pos.FullMoves())
+	switch {
+	case mt.pos.ToMove() == engine.White:
+		number = n + ". "
+	case mt.number:
+		number = n + "... "
+	}
+	mt.number = false
+	mt.add(number + mt.pos.SAN(m.Move))
+	mt.pos.MakeMove(m.Move)
+}
+
+// comments adds each comment, split into words so that long comments can be
+// wrapped.
+func (mt *movetext) comments(comments []string) {
+	for _, c := range comments {
+		words := strings.Fields(c)
+		if len(words) == 0 {
+			mt.add("{}")
+			continue
+		}
+		words[0] = "{" + words[0]
+		words[len(words)-1] += "}"
+		for _, w := range words {
+			mt.add(w)
+		}
+	}
+}
+
+// wrap writes the tokens to sb separated by spaces, starting a new line when a
+// token would take the line past maxLineLength.
+func (mt *movetext) wrap(sb *strings.Builder) {
+	var n int
+	for i, t := range mt.tokens {
+		switch {
+		case i == 0:
+		case n+1+len(t) > maxLineLength:
+			sb.WriteByte('\n')
+			n = 0
+		default:
+			sb.WriteByte(' ')
+			n++
+		}
+		sb.WriteString(t)
+		n += len(t)
+	}
+}
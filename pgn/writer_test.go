@@ -0,0 +1,114 @@
+package pgn_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/GeorgeBills/chess/engine"
+	"github.com/GeorgeBills/chess/pgn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter(t *testing.T) {
+	f, err := os.Open("testdata/games.pgn")
+	require.NoError(t, err)
+	defer f.Close()
+
+	expected, err := os.ReadFile("testdata/games.out.pgn")
+	require.NoError(t, err)
+
+	var sb strings.Builder
+	r := pgn.NewReader(f)
+	w := pgn.NewWriter(&sb)
+	for {
+		g, err := r.Read()
+		if err != nil {
+			break
+		}
+		require.NoError(t, w.Write(g))
+	}
+	assert.Equal(t, string(expected), sb.String())
+
+	for _, line := range strings.Split(sb.String(), "\n") {
+		assert.LessOrEqual(t, len(line), 80, line)
+	}
+}
+
+func TestNewGame(t *testing.T) {
+	t.Run("from the starting position", func(t *testing.T) {
+		pos := engine.NewGame(engine.NewBoard())
+		for _, san := range []string{"f3", "e5", "g4", "Qh4#"} {
+			m, err := pos.ParseSAN(san)
+			require.NoError(t, err)
+			pos.MakeMove(m)
+		}
+		before := pos.FEN()
+
+		g := pgn.NewGame(pos)
+		g.Tags = append(g.Tags, pgn.Tag{Name: "White", Value: `Fool "the" Fool`})
+		g.Moves[3].Comments = []string{"-M1/1"}
+
+		var sb strings.Builder
+		require.NoError(t, pgn.NewWriter(&sb).Write(g))
+		assert.Equal(t, `[Event "?"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "Fool \"the\" Fool"]
+[Black "?"]
+[Result "0-1"]
+
+1. f3 e5 2. g4 Qh4# {-M1/1} 0-1
+
+`, sb.String())
+		assert.Equal(t, before, pos.FEN(), "NewGame() should leave the position unchanged")
+	})
+
+	t.Run("from a FEN", func(t *testing.T) {
+		const fen = "4k3/8/8/8/8/8/4P3/4K3 b - - 0 40"
+		b, err := engine.NewBoardFromFEN(strings.NewReader(fen))
+		require.NoError(t, err)
+		pos := engine.NewGame(b)
+		for _, san := range []string{"Kd7", "e4", "Ke6"} {
+			m, err := pos.ParseSAN(san)
+			require.NoError(t, err)
+			pos.MakeMove(m)
+		}
+
+		g := pgn.NewGame(pos)
+		assert.Equal(t, "1", g.Tag("SetUp"))
+		assert.Equal(t, fen, g.Tag("FEN"))
+
+		var sb strings.Builder
+		require.NoError(t, pgn.NewWriter(&sb).Write(g))
+		assert.True(t, strings.HasSuffix(sb.String(), "\n40... Kd7 41. e4 Ke6 *\n\n"), sb.String())
+
+		// the written game should read back the same
+		read, err := pgn.NewReader(strings.NewReader(sb.String())).Read()
+		require.NoError(t, err)
+		assert.Equal(t, sans(g.Moves), sans(read.Moves))
+		assert.Equal(t, pos.FEN(), read.Position.FEN())
+	})
+}
+
+func TestWriterWrapsLongComments(t *testing.T) {
+	pos := engine.NewGame(engine.NewBoard())
+	m, err := pos.ParseSAN("e4")
+	require.NoError(t, err)
+	pos.MakeMove(m)
+
+	g := pgn.NewGame(pos)
+	g.Moves[0].Comments = []string{strings.Repeat("very ", 40) + "long"}
+
+	var sb strings.Builder
+	require.NoError(t, pgn.NewWriter(&sb).Write(g))
+	for _, line := range strings.Split(sb.String(), "\n") {
+		assert.LessOrEqual(t, len(line), 80, line)
+	}
+
+	read, err := pgn.NewReader(strings.NewReader(sb.String())).Read()
+	require.NoError(t, err)
+	assert.Equal(t, g.Moves[0].Comments, read.Moves[0].Comments)
+}
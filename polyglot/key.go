@@ -0,0 +1,110 @@
+package polyglot
+
+import (
+	"errors"
+	"math"
+
+	"github.com/GeorgeBills/chess"
+	"github.com/GeorgeBills/chess/engine"
+)
+
+// Offsets into the Random64 table.
+const (
+	offsetPiece     = 0
+	offsetCastle    = 768
+	offsetEnPassant = 772
+	offsetTurn      = 780
+
+	random64Len = 781
+)
+
+// random64 holds the 781 "random" numbers that Polyglot keys are built from.
+// These are fixed by the format and must match those in the specification
+// exactly, or no book positions will be found.
+//
+// TODO: populate this from the table in the format specification (see the
+// package documentation). Until then Key returns ErrNoRandom64, and books can
+// be read but not probed by position.
+var random64 []uint64
+
+// ErrNoRandom64 is returned by Key if the Random64 table isn't populated.
+var ErrNoRandom64 = errors.New("polyglot Random64 table isn't populated")
+
+// Key returns the Polyglot key for the position.
+func Key(b *engine.Board) (uint64, error) {
+	if len(random64) != random64Len {
+		return 0, ErrNoRandom64
+	}
+
+	var key uint64
+
+	for i := uint8(0); i < 64; i++ {
+		if kind, ok := pieceKind(b.PieceAt(i)); ok {
+			key ^= random64[offsetPiece+64*kind+int(i)]
+		}
+	}
+
+	for i, can := range [4]bool{
+		b.CanWhiteCastleKingside(),
+		b.CanWhiteCastleQueenside(),
+		b.CanBlackCastleKingside(),
+		b.CanBlackCastleQueenside(),
+	} {
+		if can {
+			key ^= random64[offsetCastle+i]
+		}
+	}
+
+	// The en passant file is only hashed if a pawn of the side to move is
+	// actually in a position to capture en passant, legal or not.
+	if ep := b.EnPassant(); ep != math.MaxUint8 && canCaptureEnPassant(b, ep) {
+		key ^= random64[offsetEnPassant+int(chess.FileIndex(ep))]
+	}
+
+	if b.ToMove() == engine.White {
+		key ^= random64[offsetTurn]
+	}
+
+	return key, nil
+}
+
+// pieceKind returns the Polyglot "kind of piece" for p: black pawn is 0, white
+// pawn is 1, black knight is 2 and so on through to white king at 11.
+func pieceKind(p engine.Piece) (int, bool) {
+	var kind int
+	switch {
+	case p&engine.PiecePawn != 0:
+		kind = 0
+	case p&engine.PieceKnight != 0:
+		kind = 2
+	case p&engine.PieceBishop != 0:
+		kind = 4
+	case p&engine.PieceRook != 0:
+		kind = 6
+	case p&engine.PieceQueen != 0:
+		kind = 8
+	case p&engine.PieceKing != 0:
+		kind = 10
+	default:
+		return 0, false
+	}
+	if p&engine.PieceWhite != 0 {
+		kind++
+	}
+	return kind, true
+}
+
+func canCaptureEnPassant(b *engine.Board, ep uint8) bool {
+	pawn, rank := engine.PieceWhitePawn, chess.RankIndex(ep)-1
+	if b.ToMove() == engine.Black {
+		pawn, rank = engine.PieceBlackPawn, chess.RankIndex(ep)+1
+	}
+	file := chess.FileIndex(ep)
+	if file > 0 && b.PieceAt(chess.SquareIndex(rank, file-1)) == pawn {
+		return true
+	}
+	if file < 7 && b.PieceAt(chess.SquareIndex(rank, file+1)) == pawn {
+		return true
+	}
+	return false
+}
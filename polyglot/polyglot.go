@@ -0,0 +1,178 @@
+// Package polyglot implements reading of opening books in the Polyglot format.
+//
+// The format is documented at http://hgm.nubati.net/book_format.html. A book
+// is a sequence of 16 byte entries, sorted by key, each holding the Polyglot
+// Zobrist key for a position, a move in that position, a weight for the move
+// and some learning data:
+//
+//	key    uint64
+//	move   uint16
+//	weight uint16
+//	learn  uint32
+//
+// All values are big endian.
+package polyglot
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"sort"
+
+	"github.com/GeorgeBills/chess"
+	"github.com/GeorgeBills/chess/engine"
+)
+
+// https://www.chessprogramming.org/PolyGlot
+
+const entrySize = 16
+
+// Entry is a single move in the book for the position with the given key.
+type Entry struct {
+	Key    uint64
+	Move   uint16
+	Weight uint16
+	Learn  uint32
+}
+
+// From returns the square index the move is coming from.
+func (e Entry) From() uint8 { return uint8(e.Move>>6) & 0b111_111 }
+
+// To returns the square index the move is going to. Castling is given as the
+// king capturing its own rook (e.g. "e1h1" for white kingside castling).
+func (e Entry) To() uint8 { return uint8(e.Move) & 0b111_111 }
+
+// PromoteTo returns the piece the move promotes to, or PromoteToNone.
+func (e Entry) PromoteTo() chess.PromoteTo {
+	switch e.Move >> 12 & 0b111 {
+	case 1:
+		return chess.PromoteToKnight
+	case 2:
+		return chess.PromoteToBishop
+	case 3:
+		return chess.PromoteToRook
+	case 4:
+		return chess.PromoteToQueen
+	default:
+		return chess.PromoteToNone
+	}
+}
+
+// Book is an opening book.
+type Book struct {
+	entries []Entry
+}
+
+// Read reads a book from r.
+func Read(r io.Reader) (*Book, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data)%entrySize != 0 {
+		return nil, fmt.Errorf("book size %d isn't a multiple of %d", len(data), entrySize)
+	}
+
+	b := &Book{entries: make([]Entry, len(data)/entrySize)}
+	for i := range b.entries {
+		entry := data[i*entrySize : (i+1)*entrySize]
+		b.entries[i] = Entry{
+			Key:    binary.BigEndian.Uint64(entry[0:8]),
+			Move:   binary.BigEndian.Uint16(entry[8:10]),
+			Weight: binary.BigEndian.Uint16(entry[10:12]),
+			Learn:  binary.BigEndian.Uint32(entry[12:16]),
+		}
+	}
+
+	if !sort.SliceIsSorted(b.entries, func(i, j int) bool { return b.entries[i].Key < b.entries[j].Key }) {
+		return nil, errors.New("book entries aren't sorted by key")
+	}
+
+	return b, nil
+}
+
+// Len returns the number of entries in the book.
+func (b *Book) Len() int { return len(b.entries) }
+
+// Entries returns the entries for the position with the given key.
+func (b *Book) Entries(key uint64) []Entry {
+	i := sort.Search(len(b.entries), func(i int) bool { return b.entries[i].Key >= key })
+	j := i
+	for j < len(b.entries) && b.entries[j].Key == key {
+		j++
+	}
+	return b.entries[i:j]
+}
+
+// Move returns a book move for the position, and false if there isn't one.
+// See Choose for how the move is chosen.
+func (b *Book) Move(pos *engine.Board, rnd *rand.Rand, best bool) (engine.Move, bool, error) {
+	key, err := Key(pos)
+	if err != nil {
+		return 0, false, err
+	}
+	m, ok := Choose(pos, b.Entries(key), rnd, best)
+	return m, ok, nil
+}
+
+// Choose chooses a move for the position from entries, and returns false if
+// there isn't one.
+//
+// If best is true then the move with the highest weight is returned, else a
+// move is chosen at random with probability proportional to its weight. Moves
+// with a weight of zero are never chosen, and moves that aren't legal in the
+// position (e.g. because of a key collision) are ignored.
+func Choose(pos *engine.Board, entries []Entry, rnd *rand.Rand, best bool) (engine.Move, bool) {
+	legal, _ := pos.GenerateLegalMoves(nil)
+
+	var moves []engine.Move
+	var weights []int
+	var total int
+	for _, e := range entries {
+		if e.Weight == 0 {
+			continue
+		}
+		m, err := pos.HydrateMove(e)
+		if err != nil || !contains(legal, m) {
+			continue
+		}
+		moves = append(moves, m)
+		weights = append(weights, int(e.Weight))
+		total += int(e.Weight)
+	}
+
+	if len(moves) == 0 {
+		return 0, false
+	}
+
+	if best {
+		i := 0
+		for j := range moves {
+			if weights[j] > weights[i] {
+				i = j
+			}
+		}
+		return moves[i], true
+	}
+
+	n := rnd.Intn(total)
+	for i, w := range weights {
+		if n < w {
+			return moves[i], true
+		}
+		n -= w
+	}
+	panic("unreachable")
+}
+
+func contains(moves []engine.Move, m engine.Move) bool {
+	for _, legal := range moves {
+		if legal == m {
+			return true
+		}
+	}
+	return false
+}
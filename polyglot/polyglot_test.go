@@ -0,0 +1,228 @@
+package polyglot_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/GeorgeBills/chess"
+	"github.com/GeorgeBills/chess/engine"
+	"github.com/GeorgeBills/chess/polyglot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func book(t *testing.T, entries ...polyglot.Entry) []byte {
+	var buf bytes.Buffer
+	for _, e := range entries {
+		require.NoError(t, binary.Write(&buf, binary.BigEndian, e))
+	}
+	return buf.Bytes()
+}
+
+// entry returns a book entry with a move from square index from to square
+// index to, promoting to the Polyglot promotion piece promote.
+func entry(key uint64, from, to uint8, promote uint16, weight uint16) polyglot.Entry {
+	return polyglot.Entry{Key: key, Move: promote<<12 | uint16(from)<<6 | uint16(to), Weight: weight}
+}
+
+func TestRead(t *testing.T) {
+	data := book(t,
+		polyglot.Entry{Key: 1, Move: 0x031c, Weight: 10, Learn: 0},
+		polyglot.Entry{Key: 2, Move: 0x0314, Weight: 20, Learn: 0},
+		polyglot.Entry{Key: 2, Move: 0x0355, Weight: 30, Learn: 7},
+		polyglot.Entry{Key: 4, Move: 0x0104, Weight: 40, Learn: 0},
+	)
+
+	b, err := polyglot.Read(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 4, b.Len())
+
+	assert.Equal(t, []polyglot.Entry{{Key: 1, Move: 0x031c, Weight: 10}}, b.Entries(1))
+	assert.Equal(t, []polyglot.Entry{
+		{Key: 2, Move: 0x0314, Weight: 20},
+		{Key: 2, Move: 0x0355, Weight: 30, Learn: 7},
+	}, b.Entries(2))
+	assert.Empty(t, b.Entries(0))
+	assert.Empty(t, b.Entries(3))
+	assert.Empty(t, b.Entries(5))
+}
+
+func TestReadInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		{
+			"truncated",
+			make([]byte, 20),
+			"book size 20 isn't a multiple of 16",
+		},
+		{
+			"unsorted",
+			book(t, polyglot.Entry{Key: 2}, polyglot.Entry{Key: 1}),
+			"book entries aren't sorted by key",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := polyglot.Read(bytes.NewReader(tt.data))
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
+
+func TestEntry(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   polyglot.Entry
+		from    uint8
+		to      uint8
+		promote chess.PromoteTo
+	}{
+		{"e2e4", entry(0, engine.E2, engine.E4, 0, 1), engine.E2, engine.E4, chess.PromoteToNone},
+		{"g8f6", entry(0, engine.G8, engine.F6, 0, 1), engine.G8, engine.F6, chess.PromoteToNone},
+		{"castling", entry(0, engine.E1, engine.H1, 0, 1), engine.E1, engine.H1, chess.PromoteToNone},
+		{"knight promotion", entry(0, engine.A7, engine.A8, 1, 1), engine.A7, engine.A8, chess.PromoteToKnight},
+		{"bishop promotion", entry(0, engine.B2, engine.A1, 2, 1), engine.B2, engine.A1, chess.PromoteToBishop},
+		{"rook promotion", entry(0, engine.H7, engine.H8, 3, 1), engine.H7, engine.H8, chess.PromoteToRook},
+		{"queen promotion", entry(0, engine.C2, engine.C1, 4, 1), engine.C2, engine.C1, chess.PromoteToQueen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.from, tt.entry.From())
+			assert.Equal(t, tt.to, tt.entry.To())
+			assert.Equal(t, tt.promote, tt.entry.PromoteTo())
+		})
+	}
+}
+
+func TestChoose(t *testing.T) {
+	const castlingFEN = "r3k2r/pppppppp/8/8/8/8/PPPPPPPP/R3K2R w KQkq - 0 1"
+
+	tests := []struct {
+		name     string
+		fen      string
+		entries  []polyglot.Entry
+		expected string // SAN, or empty if no move should be chosen
+	}{
+		{
+			"highest weight",
+			engine.InitialBoardFEN,
+			[]polyglot.Entry{
+				entry(1, engine.E2, engine.E4, 0, 10),
+				entry(1, engine.D2, engine.D4, 0, 30),
+				entry(1, engine.G1, engine.F3, 0, 20),
+			},
+			"d2d4",
+		},
+		{
+			"illegal move ignored",
+			engine.InitialBoardFEN,
+			[]polyglot.Entry{
+				entry(1, engine.E2, engine.E5, 0, 50),
+				entry(1, engine.C2, engine.C4, 0, 1),
+			},
+			"c2c4",
+		},
+		{
+			"zero weight ignored",
+			engine.InitialBoardFEN,
+			[]polyglot.Entry{entry(1, engine.E2, engine.E4, 0, 0)},
+			"",
+		},
+		{
+			"no entries",
+			engine.InitialBoardFEN,
+			nil,
+			"",
+		},
+		{
+			"kingside castling",
+			castlingFEN,
+			[]polyglot.Entry{entry(1, engine.E1, engine.H1, 0, 1)},
+			"O-O",
+		},
+		{
+			"queenside castling",
+			castlingFEN,
+			[]polyglot.Entry{entry(1, engine.E1, engine.A1, 0, 1)},
+			"O-O-O",
+		},
+		{
+			"promotion",
+			"8/P6k/8/8/8/8/8/K7 w - - 0 1",
+			[]polyglot.Entry{entry(1, engine.A7, engine.A8, 1, 1)},
+			"a7a8=N",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := engine.NewBoardFromFEN(strings.NewReader(tt.fen))
+			require.NoError(t, err)
+			m, ok := polyglot.Choose(b, tt.entries, rand.New(rand.NewSource(1)), true)
+			if tt.expected == "" {
+				assert.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			assert.Equal(t, tt.expected, m.SAN())
+		})
+	}
+}
+
+func TestChooseWeighted(t *testing.T) {
+	entries := []polyglot.Entry{
+		entry(1, engine.E2, engine.E4, 0, 3),
+		entry(1, engine.D2, engine.D4, 0, 1),
+		entry(1, engine.G1, engine.F3, 0, 0),
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	counts := make(map[string]int)
+	const n = 4000
+	for i := 0; i < n; i++ {
+		m, ok := polyglot.Choose(engine.NewBoard(), entries, rnd, false)
+		require.True(t, ok)
+		counts[m.SAN()]++
+	}
+
+	assert.Len(t, counts, 2)
+	assert.InDelta(t, n*3/4, counts["e2e4"], n/20)
+	assert.InDelta(t, n*1/4, counts["d2d4"], n/20)
+}
+
+func TestKey(t *testing.T) {
+	// Test vectors from the format specification.
+	tests := []struct {
+		name     string
+		fen      string
+		expected uint64
+	}{
+		{"starting position", engine.InitialBoardFEN, 0x463b96181691fc9c},
+		{"e4", "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1", 0x823c9b50fd114196},
+		{"e4 d5", "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 2", 0x0756b94461c50fb0},
+		{"e4 d5 e5", "rnbqkbnr/ppp1pppp/8/3pP3/8/8/PPPP1PPP/RNBQKBNR b KQkq - 0 2", 0x662fafb965db29d4},
+		{"e4 d5 e5 f5", "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", 0x22a48b5a8e47ff78},
+		{"e4 d5 e5 f5 Ke2", "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPPKPPP/RNBQ1BNR b kq - 0 3", 0x652a607ca3f242c1},
+		{"e4 d5 e5 f5 Ke2 Kf7", "rnbq1bnr/ppp1pkpp/8/3pPp2/8/8/PPPPKPPP/RNBQ1BNR w - - 0 4", 0x00fdd303c946bdd9},
+		{"a4 b5 h4 b4 c4", "rnbqkbnr/p1pppppp/8/8/PpP4P/8/1P1PPPP1/RNBQKBNR b KQkq c3 0 3", 0x3c8123ea7b067637},
+		{"a4 b5 h4 b4 c4 bxc3 Ra3", "rnbqkbnr/p1pppppp/8/8/P6P/R1p5/1P1PPPP1/1NBQKBNR b Kkq - 0 4", 0x5c3f9b829b279560},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := engine.NewBoardFromFEN(strings.NewReader(tt.fen))
+			require.NoError(t, err)
+			key, err := polyglot.Key(b)
+			if errors.Is(err, polyglot.ErrNoRandom64) {
+				t.Skip(err)
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, key)
+		})
+	}
+}
@@ -1,17 +1,30 @@
 package uci
 
-import "github.com/GeorgeBills/chess"
+import (
+	"time"
+
+	"github.com/GeorgeBills/chess"
+)
 
 //go:generate moq -out mocks/adapter.go -pkg mocks . Adapter
 
 // Adapter handles events generated from parsing UCI.
+//
+// searchmoves restricts each search to those moves at the root. It's nil if the
+// search isn't restricted.
 type Adapter interface {
 	Identify() (name, author string, other map[string]string)
+	Options() []Option
+	SetOption(name string, value OptionValue) error
 	NewGame() error
 	SetStartingPosition(moves []chess.FromToPromoter) error
 	SetPositionFEN(fen string, moves []chess.FromToPromoter) error
-	GoDepth(plies uint8, stopch <-chan struct{}, infoch chan<- Response) (chess.FromToPromoter, error)
-	GoNodes(nodes uint64, stopch <-chan struct{}, infoch chan<- Response) (chess.FromToPromoter, error)
-	GoTime(tc TimeControl, stopch <-chan struct{}, infoch chan<- Response) (chess.FromToPromoter, error)
-	GoInfinite(stopch <-chan struct{}, infoch chan<- Response) (chess.FromToPromoter, error)
+	GoDepth(plies uint8, searchmoves []chess.FromToPromoter, stopch <-chan struct{}, infoch chan<- Response) (chess.FromToPromoter, error)
+	GoNodes(nodes uint64, searchmoves []chess.FromToPromoter, stopch <-chan struct{}, infoch chan<- Response) (chess.FromToPromoter, error)
+	GoTime(tc TimeControl, searchmoves []chess.FromToPromoter, stopch <-chan struct{}, infoch chan<- Response) (chess.FromToPromoter, error)
+	GoMoveTime(movetime time.Duration, searchmoves []chess.FromToPromoter, stopch <-chan struct{}, infoch chan<- Response) (chess.FromToPromoter, error)
+	GoMate(moves uint8, movetime time.Duration, tc TimeControl, searchmoves []chess.FromToPromoter, stopch <-chan struct{}, infoch chan<- Response) (chess.FromToPromoter, error)
+	GoInfinite(searchmoves []chess.FromToPromoter, stopch <-chan struct{}, infoch chan<- Response) (chess.FromToPromoter, error)
+	GoPonder(movetime time.Duration, tc TimeControl, ponderhitch <-chan struct{}, searchmoves []chess.FromToPromoter, stopch <-chan struct{}, infoch chan<- Response) (chess.FromToPromoter, error)
+	PonderMove() chess.FromToPromoter
 }
@@ -1,9 +1,11 @@
 package uci
 
 import (
+	"fmt"
 	"io"
 	"log"
 	"sort"
+	"time"
 
 	"github.com/GeorgeBills/chess"
 )
@@ -77,11 +79,32 @@ func (c CommandUCI) Execute(a Adapter, responsech chan<- Response, stopch <-chan
 		responsech <- ResponseID{k, rest[k]}
 	}
 
+	// respond with the options we support
+	for _, o := range a.Options() {
+		responsech <- ResponseOption{o}
+	}
+
 	responsech <- ResponseOK{}
 
 	return nil
 }
 
+type CommandSetOption struct {
+	Name, Value string
+}
+
+func (c CommandSetOption) Execute(a Adapter, responsech chan<- Response, stopch <-chan struct{}) error {
+	o, ok := findOption(a.Options(), c.Name)
+	if !ok {
+		return fmt.Errorf("no such option: %s", c.Name)
+	}
+	value, err := o.Parse(c.Value)
+	if err != nil {
+		return err
+	}
+	return a.SetOption(o.Name, value)
+}
+
 type CommandNewGame struct{}
 
 func (c CommandNewGame) Execute(a Adapter, responsech chan<- Response, stopch <-chan struct{}) error {
@@ -121,51 +144,114 @@ func (c *CommandSetPositionFEN) AppendMove(m *Move) {
 }
 
 type CommandGoNodes struct {
-	Nodes uint64
+	Nodes       uint64
+	SearchMoves []chess.FromToPromoter
 }
 
 func (c CommandGoNodes) Execute(a Adapter, responsech chan<- Response, stopch <-chan struct{}) error {
-	movestr, err := a.GoNodes(c.Nodes, stopch, responsech)
+	movestr, err := a.GoNodes(c.Nodes, c.SearchMoves, stopch, responsech)
 	if err != nil {
 		return err
 	}
-	responsech <- ResponseBestMove{movestr}
+	responsech <- bestMove(a, movestr)
 	return nil
 }
 
 type CommandGoDepth struct {
-	Plies uint8
+	Plies       uint8
+	SearchMoves []chess.FromToPromoter
 }
 
 func (c CommandGoDepth) Execute(a Adapter, responsech chan<- Response, stopch <-chan struct{}) error {
-	movestr, err := a.GoDepth(c.Plies, stopch, responsech)
+	movestr, err := a.GoDepth(c.Plies, c.SearchMoves, stopch, responsech)
 	if err != nil {
 		return err
 	}
-	responsech <- ResponseBestMove{movestr}
+	responsech <- bestMove(a, movestr)
 	return nil
 }
 
-type CommandGoInfinite struct{}
+type CommandGoInfinite struct {
+	SearchMoves []chess.FromToPromoter
+}
 
 func (c CommandGoInfinite) Execute(a Adapter, responsech chan<- Response, stopch <-chan struct{}) error {
-	move, err := a.GoInfinite(stopch, responsech)
+	move, err := a.GoInfinite(c.SearchMoves, stopch, responsech)
 	if err != nil {
 		return err
 	}
-	responsech <- ResponseBestMove{move}
+	responsech <- bestMove(a, move)
 	return nil
 }
 
 type CommandGoTime struct {
 	TimeControl
+	SearchMoves []chess.FromToPromoter
 }
 
 func (c CommandGoTime) Execute(a Adapter, responsech chan<- Response, stopch <-chan struct{}) error {
-	move, err := a.GoTime(c.TimeControl, stopch, responsech)
+	move, err := a.GoTime(c.TimeControl, c.SearchMoves, stopch, responsech)
+	if err != nil {
+		return err
+	}
+	responsech <- bestMove(a, move)
+	return nil
+}
+
+type CommandGoMoveTime struct {
+	MoveTime    time.Duration
+	SearchMoves []chess.FromToPromoter
+}
+
+func (c CommandGoMoveTime) Execute(a Adapter, responsech chan<- Response, stopch <-chan struct{}) error {
+	move, err := a.GoMoveTime(c.MoveTime, c.SearchMoves, stopch, responsech)
 	if err != nil {
 		return err
 	}
-	responsech <- ResponseBestMove{move}
+	responsech <- bestMove(a, move)
 	return nil
 }
+
+// CommandGoPonder searches while the opponent is thinking, on the position
+// after the move we expect them to play. PonderHit is closed if they play that
+// move, after which the search continues as it would for CommandGoMoveTime (if
+// MoveTime is set) or CommandGoTime.
+type CommandGoPonder struct {
+	MoveTime time.Duration
+	TimeControl
+	PonderHit   <-chan struct{}
+	SearchMoves []chess.FromToPromoter
+}
+
+func (c CommandGoPonder) Execute(a Adapter, responsech chan<- Response, stopch <-chan struct{}) error {
+	move, err := a.GoPonder(c.MoveTime, c.TimeControl, c.PonderHit, c.SearchMoves, stopch, responsech)
+	if err != nil {
+		return err
+	}
+	responsech <- bestMove(a, move)
+	return nil
+}
+
+// CommandGoMate searches for a mate in the given number of moves. The search
+// may be limited by an exact move time or by the clock, if they're given.
+type CommandGoMate struct {
+	Moves    uint8
+	MoveTime time.Duration
+	TimeControl
+	SearchMoves []chess.FromToPromoter
+}
+
+func (c CommandGoMate) Execute(a Adapter, responsech chan<- Response, stopch <-chan struct{}) error {
+	move, err := a.GoMate(c.Moves, c.MoveTime, c.TimeControl, c.SearchMoves, stopch, responsech)
+	if err != nil {
+		return err
+	}
+	responsech <- bestMove(a, move)
+	return nil
+}
+
+// bestMove returns the response for the best move from a search, along with the
+// move the adapter expects the opponent to reply with, if any.
+func bestMove(a Adapter, move chess.FromToPromoter) ResponseBestMove {
+	return ResponseBestMove{move, a.PonderMove()}
+}
@@ -24,23 +24,43 @@ func TestExecuteCommands(t *testing.T) {
 				"release-date": "2020-05-26",
 			}
 		},
+		OptionsFunc: func() []uci.Option {
+			return []uci.Option{
+				{Name: "Hash", Type: uci.OptionTypeSpin, Default: "16", Min: 1, Max: 1024},
+				{Name: "Clear Hash", Type: uci.OptionTypeButton},
+			}
+		},
+		SetOptionFunc:           func(string, uci.OptionValue) error { return nil },
 		NewGameFunc:             func() error { return nil },
 		SetStartingPositionFunc: func([]chess.FromToPromoter) error { return nil },
 		SetPositionFENFunc:      func(string, []chess.FromToPromoter) error { return nil },
-		GoDepthFunc: func(plies uint8, stopch <-chan struct{}, infoch chan<- uci.Response) (chess.FromToPromoter, error) {
+		GoDepthFunc: func(plies uint8, searchmoves []chess.FromToPromoter, stopch <-chan struct{}, infoch chan<- uci.Response) (chess.FromToPromoter, error) {
 			return mustParseMove("a1a2"), nil
 		},
-		GoNodesFunc: func(nodes uint64, stopch <-chan struct{}, infoch chan<- uci.Response) (chess.FromToPromoter, error) {
+		GoNodesFunc: func(nodes uint64, searchmoves []chess.FromToPromoter, stopch <-chan struct{}, infoch chan<- uci.Response) (chess.FromToPromoter, error) {
 			return mustParseMove("b3b4"), nil
 		},
-		GoTimeFunc: func(tc uci.TimeControl, stopch <-chan struct{}, infoch chan<- uci.Response) (chess.FromToPromoter, error) {
+		GoTimeFunc: func(tc uci.TimeControl, searchmoves []chess.FromToPromoter, stopch <-chan struct{}, infoch chan<- uci.Response) (chess.FromToPromoter, error) {
 			return mustParseMove("c5c6"), nil
 		},
-		GoInfiniteFunc: func(stopch <-chan struct{}, infoch chan<- uci.Response) (chess.FromToPromoter, error) {
+		GoMoveTimeFunc: func(movetime time.Duration, searchmoves []chess.FromToPromoter, stopch <-chan struct{}, infoch chan<- uci.Response) (chess.FromToPromoter, error) {
+			return mustParseMove("e2e4"), nil
+		},
+		GoMateFunc: func(moves uint8, movetime time.Duration, tc uci.TimeControl, searchmoves []chess.FromToPromoter, stopch <-chan struct{}, infoch chan<- uci.Response) (chess.FromToPromoter, error) {
+			return mustParseMove("f7f8q"), nil
+		},
+		GoInfiniteFunc: func(searchmoves []chess.FromToPromoter, stopch <-chan struct{}, infoch chan<- uci.Response) (chess.FromToPromoter, error) {
 			return mustParseMove("d7d8"), nil
 		},
+		GoPonderFunc: func(movetime time.Duration, tc uci.TimeControl, ponderhitch <-chan struct{}, searchmoves []chess.FromToPromoter, stopch <-chan struct{}, infoch chan<- uci.Response) (chess.FromToPromoter, error) {
+			return mustParseMove("g1f3"), nil
+		},
 	}
 
+	// only the ponder search expects a reply
+	var ponder chess.FromToPromoter
+	a.PonderMoveFunc = func() chess.FromToPromoter { return ponder }
+
 	commandch := make(chan uci.Command)
 	stopch := make(chan struct{})
 	e, responsech := uci.NewExecutor(commandch, stopch, a, os.Stdout)
@@ -64,6 +84,12 @@ func TestExecuteCommands(t *testing.T) {
 		version := timeoutReadResponse(t, responsech)
 		assert.Equal(t, uci.ResponseID{Key: "version", Value: "1.2.3"}, version)
 
+		hash := timeoutReadResponse(t, responsech)
+		assert.Equal(t, uci.ResponseOption{uci.Option{Name: "Hash", Type: uci.OptionTypeSpin, Default: "16", Min: 1, Max: 1024}}, hash)
+
+		clear := timeoutReadResponse(t, responsech)
+		assert.Equal(t, uci.ResponseOption{uci.Option{Name: "Clear Hash", Type: uci.OptionTypeButton}}, clear)
+
 		ok := timeoutReadResponse(t, responsech)
 		assert.Equal(t, uci.ResponseOK{}, ok)
 	})
@@ -99,6 +125,32 @@ func TestExecuteCommands(t *testing.T) {
 		}
 	})
 
+	t.Run("setoption", func(t *testing.T) {
+		commandch <- uci.CommandSetOption{Name: "hash", Value: "128"}
+		time.Sleep(processing)
+		commandch <- uci.CommandSetOption{Name: "Clear Hash"}
+		time.Sleep(processing)
+
+		calls := a.SetOptionCalls()
+		if assert.Len(t, calls, 2) {
+			assert.Equal(t, "Hash", calls[0].Name)
+			assert.Equal(t, uci.OptionValue{Spin: 128}, calls[0].Value)
+			assert.Equal(t, "Clear Hash", calls[1].Name)
+		}
+	})
+
+	t.Run("setoption invalid", func(t *testing.T) {
+		commandch <- uci.CommandSetOption{Name: "Hash", Value: "1025"}
+		time.Sleep(processing)
+		commandch <- uci.CommandSetOption{Name: "Hash", Value: "lots"}
+		time.Sleep(processing)
+		commandch <- uci.CommandSetOption{Name: "Nullmove", Value: "true"}
+		time.Sleep(processing)
+
+		// none of these should make it to the adapter
+		assert.Len(t, a.SetOptionCalls(), 2)
+	})
+
 	t.Run("position fen", func(t *testing.T) {
 		cmd := &uci.CommandSetPositionFEN{
 			FEN: "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
@@ -120,7 +172,8 @@ func TestExecuteCommands(t *testing.T) {
 	})
 
 	t.Run("go depth", func(t *testing.T) {
-		commandch <- uci.CommandGoDepth{Plies: 123}
+		searchmoves := []chess.FromToPromoter{mustParseMove("a1a2"), mustParseMove("h7h8q")}
+		commandch <- uci.CommandGoDepth{Plies: 123, SearchMoves: searchmoves}
 		time.Sleep(processing)
 
 		calls := a.GoDepthCalls()
@@ -128,10 +181,11 @@ func TestExecuteCommands(t *testing.T) {
 			assert.NotNil(t, calls[0].Infoch)
 			assert.NotNil(t, calls[0].Stopch)
 			assert.EqualValues(t, 123, calls[0].Plies)
+			assert.Equal(t, searchmoves, calls[0].Searchmoves)
 		}
 
 		response := timeoutReadResponse(t, responsech)
-		assert.Equal(t, uci.ResponseBestMove{mustParseMove("a1a2")}, response)
+		assert.Equal(t, uci.ResponseBestMove{Move: mustParseMove("a1a2")}, response)
 	})
 
 	t.Run("go nodes", func(t *testing.T) {
@@ -146,12 +200,12 @@ func TestExecuteCommands(t *testing.T) {
 		}
 
 		response := timeoutReadResponse(t, responsech)
-		assert.Equal(t, uci.ResponseBestMove{mustParseMove("b3b4")}, response)
+		assert.Equal(t, uci.ResponseBestMove{Move: mustParseMove("b3b4")}, response)
 	})
 
 	t.Run("go time", func(t *testing.T) {
 		commandch <- uci.CommandGoTime{
-			uci.TimeControl{
+			TimeControl: uci.TimeControl{
 				WhiteTime:      1 * time.Minute,
 				BlackTime:      2 * time.Minute,
 				WhiteIncrement: 3 * time.Second,
@@ -168,7 +222,42 @@ func TestExecuteCommands(t *testing.T) {
 		}
 
 		response := timeoutReadResponse(t, responsech)
-		assert.Equal(t, uci.ResponseBestMove{mustParseMove("c5c6")}, response)
+		assert.Equal(t, uci.ResponseBestMove{Move: mustParseMove("c5c6")}, response)
+	})
+
+	t.Run("go movetime", func(t *testing.T) {
+		commandch <- uci.CommandGoMoveTime{MoveTime: 5 * time.Second}
+		time.Sleep(processing)
+
+		calls := a.GoMoveTimeCalls()
+		if assert.Len(t, calls, 1) {
+			assert.NotNil(t, calls[0].Infoch)
+			assert.NotNil(t, calls[0].Stopch)
+			assert.Equal(t, 5*time.Second, calls[0].Movetime)
+		}
+
+		response := timeoutReadResponse(t, responsech)
+		assert.Equal(t, uci.ResponseBestMove{Move: mustParseMove("e2e4")}, response)
+	})
+
+	t.Run("go mate", func(t *testing.T) {
+		commandch <- uci.CommandGoMate{
+			Moves:       3,
+			TimeControl: uci.TimeControl{WhiteTime: 1 * time.Minute, BlackTime: 2 * time.Minute},
+		}
+		time.Sleep(processing)
+
+		calls := a.GoMateCalls()
+		if assert.Len(t, calls, 1) {
+			assert.NotNil(t, calls[0].Infoch)
+			assert.NotNil(t, calls[0].Stopch)
+			assert.EqualValues(t, 3, calls[0].Moves)
+			assert.Zero(t, calls[0].Movetime)
+			assert.Equal(t, "wtime 60000 btime 120000 winc 0 binc 0", calls[0].Tc.String())
+		}
+
+		response := timeoutReadResponse(t, responsech)
+		assert.Equal(t, uci.ResponseBestMove{Move: mustParseMove("f7f8q")}, response)
 	})
 
 	t.Run("go infinite", func(t *testing.T) {
@@ -182,7 +271,31 @@ func TestExecuteCommands(t *testing.T) {
 		}
 
 		response := timeoutReadResponse(t, responsech)
-		assert.Equal(t, uci.ResponseBestMove{mustParseMove("d7d8")}, response)
+		assert.Equal(t, uci.ResponseBestMove{Move: mustParseMove("d7d8")}, response)
+	})
+
+	t.Run("go ponder", func(t *testing.T) {
+		ponder = mustParseMove("b8c6")
+		defer func() { ponder = nil }()
+
+		ponderhitch := make(chan struct{})
+		commandch <- uci.CommandGoPonder{
+			TimeControl: uci.TimeControl{WhiteTime: 1 * time.Minute, BlackTime: 2 * time.Minute},
+			PonderHit:   ponderhitch,
+		}
+		time.Sleep(processing)
+
+		calls := a.GoPonderCalls()
+		if assert.Len(t, calls, 1) {
+			assert.NotNil(t, calls[0].Infoch)
+			assert.NotNil(t, calls[0].Stopch)
+			assert.Equal(t, (<-chan struct{})(ponderhitch), calls[0].Ponderhitch)
+			assert.Zero(t, calls[0].Movetime)
+			assert.Equal(t, "wtime 60000 btime 120000 winc 0 binc 0", calls[0].Tc.String())
+		}
+
+		response := timeoutReadResponse(t, responsech)
+		assert.Equal(t, uci.ResponseBestMove{Move: mustParseMove("g1f3"), Ponder: mustParseMove("b8c6")}, response)
 	})
 
 	close(stopch)
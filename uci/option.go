@@ -0,0 +1,87 @@
+package uci
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// OptionType is the type of an option the engine supports.
+type OptionType string
+
+// Option types as defined by the UCI specification.
+const (
+	OptionTypeCheck  OptionType = "check"  // a checkbox that is either true or false
+	OptionTypeSpin   OptionType = "spin"   // an integer in a certain range
+	OptionTypeCombo  OptionType = "combo"  // one of a list of predefined strings
+	OptionTypeButton OptionType = "button" // a button that performs an action when pressed
+	OptionTypeString OptionType = "string" // a text field
+)
+
+// Option is an option the engine supports, declared to the GUI in response to
+// the "uci" command. The GUI may then change the option with "setoption".
+type Option struct {
+	Name    string
+	Type    OptionType
+	Default string   // the default value; not used for buttons
+	Min     int64    // the minimum value for spin options
+	Max     int64    // the maximum value for spin options
+	Vars    []string // the predefined values for combo options
+}
+
+// OptionValue is a validated value for an option. Which field is set depends on
+// the type of the option: Check for check options, Spin for spin options, and
+// String for combo and string options. Buttons have no value.
+type OptionValue struct {
+	Check  bool
+	Spin   int64
+	String string
+}
+
+// Parse validates value against the option, returning the typed value.
+func (o Option) Parse(value string) (OptionValue, error) {
+	switch o.Type {
+	case OptionTypeCheck:
+		switch value {
+		case "true":
+			return OptionValue{Check: true}, nil
+		case "false":
+			return OptionValue{Check: false}, nil
+		default:
+			return OptionValue{}, fmt.Errorf("invalid value for check option %s: %q", o.Name, value)
+		}
+	case OptionTypeSpin:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return OptionValue{}, fmt.Errorf("invalid value for spin option %s: %w", o.Name, err)
+		}
+		if n < o.Min || n > o.Max {
+			return OptionValue{}, fmt.Errorf("value for spin option %s out of range [%d, %d]: %d", o.Name, o.Min, o.Max, n)
+		}
+		return OptionValue{Spin: n}, nil
+	case OptionTypeCombo:
+		for _, v := range o.Vars {
+			if strings.EqualFold(v, value) {
+				return OptionValue{String: v}, nil
+			}
+		}
+		return OptionValue{}, fmt.Errorf("invalid value for combo option %s: %q", o.Name, value)
+	case OptionTypeButton:
+		return OptionValue{}, nil
+	case OptionTypeString:
+		return OptionValue{String: value}, nil
+	default:
+		return OptionValue{}, fmt.Errorf("unknown type for option %s: %q", o.Name, o.Type)
+	}
+}
+
+// findOption returns the option with the given name. Option names are case
+// insensitive.
+func findOption(options []Option, name string) (Option, bool) {
+	for _, o := range options {
+		if strings.EqualFold(o.Name, name) {
+			return o, true
+		}
+	}
+	return Option{}, false
+}
@@ -0,0 +1,52 @@
+package uci_test
+
+import (
+	"testing"
+
+	"github.com/GeorgeBills/chess/uci"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptionParse(t *testing.T) {
+	var (
+		check  = uci.Option{Name: "Nullmove", Type: uci.OptionTypeCheck, Default: "true"}
+		spin   = uci.Option{Name: "Hash", Type: uci.OptionTypeSpin, Default: "16", Min: 1, Max: 1024}
+		combo  = uci.Option{Name: "Style", Type: uci.OptionTypeCombo, Default: "Normal", Vars: []string{"Solid", "Normal", "Risky"}}
+		button = uci.Option{Name: "Clear Hash", Type: uci.OptionTypeButton}
+		str    = uci.Option{Name: "NalimovPath", Type: uci.OptionTypeString}
+	)
+	tests := []struct {
+		name     string
+		option   uci.Option
+		value    string
+		expected uci.OptionValue
+		err      bool
+	}{
+		{"check true", check, "true", uci.OptionValue{Check: true}, false},
+		{"check false", check, "false", uci.OptionValue{Check: false}, false},
+		{"check invalid", check, "yes", uci.OptionValue{}, true},
+		{"spin", spin, "128", uci.OptionValue{Spin: 128}, false},
+		{"spin min", spin, "1", uci.OptionValue{Spin: 1}, false},
+		{"spin max", spin, "1024", uci.OptionValue{Spin: 1024}, false},
+		{"spin below min", spin, "0", uci.OptionValue{}, true},
+		{"spin above max", spin, "1025", uci.OptionValue{}, true},
+		{"spin invalid", spin, "lots", uci.OptionValue{}, true},
+		{"combo", combo, "Risky", uci.OptionValue{String: "Risky"}, false},
+		{"combo case insensitive", combo, "solid", uci.OptionValue{String: "Solid"}, false},
+		{"combo invalid", combo, "Aggressive", uci.OptionValue{}, true},
+		{"button", button, "", uci.OptionValue{}, false},
+		{"string", str, `c:\chess\tb\4`, uci.OptionValue{String: `c:\chess\tb\4`}, false},
+		{"string empty", str, "", uci.OptionValue{String: ""}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := tt.option.Parse(tt.value)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, value)
+		})
+	}
+}
@@ -10,30 +10,39 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/GeorgeBills/chess"
 )
 
 // GUI-to-engine constants are tokens sent from the GUI to the engine.
 const (
-	gteUCI        = "uci"        // tell engine to use the universal chess interface
-	gteDebug      = "debug"      // switch the debug mode of the engine on and off
-	gteIsReady    = "isready"    // used to synchronize the engine with the GUI
-	gteSetOption  = "setoption"  // change internal parameters of the engine
-	gteNewGame    = "ucinewgame" // the next search will be from a different game
-	gtePosition   = "position"   // set up the position described on the internal board
-	gteStartPos   = "startpos"   // game was played from the start position
-	gteFEN        = "fen"        // position described in fenstring
-	gteMoves      = "moves"      // play the moves on the internal chess board
-	gteGo         = "go"         // start calculating on the current position
-	gteGoDepth    = "depth"      // search x plies only
-	gteGoNodes    = "nodes"      // search x nodes only
-	gteGoMoveTime = "movetime"   // search exactly x mseconds
-	gteGoInfinite = "infinite"   // search until the stop command
-	gteWhiteTime  = "wtime"      // white has x msec left on the clock
-	gteBlackTime  = "btime"      // black has x msec left on the clock
-	gteWhiteInc   = "winc"       // white increment per move in mseconds
-	gteBlackInc   = "binc"       //	black increment per move in mseconds
-	gteStop       = "stop"       // stop calculating as soon as possible
-	gteQuit       = "quit"       // quit the program as soon as possible
+	gteUCI           = "uci"         // tell engine to use the universal chess interface
+	gteDebug         = "debug"       // switch the debug mode of the engine on and off
+	gteIsReady       = "isready"     // used to synchronize the engine with the GUI
+	gteSetOption     = "setoption"   // change internal parameters of the engine
+	gteName          = "name"        // the name of the option to change
+	gteValue         = "value"       // the value to change the option to
+	gteNewGame       = "ucinewgame"  // the next search will be from a different game
+	gtePosition      = "position"    // set up the position described on the internal board
+	gteStartPos      = "startpos"    // game was played from the start position
+	gteFEN           = "fen"         // position described in fenstring
+	gteMoves         = "moves"       // play the moves on the internal chess board
+	gteGo            = "go"          // start calculating on the current position
+	gteGoDepth       = "depth"       // search x plies only
+	gteGoNodes       = "nodes"       // search x nodes only
+	gteGoMoveTime    = "movetime"    // search exactly x mseconds
+	gteGoMate        = "mate"        // search for a mate in x moves
+	gteGoInfinite    = "infinite"    // search until the stop command
+	gteGoPonder      = "ponder"      // search in pondering mode
+	gteGoSearchMoves = "searchmoves" // restrict search to these moves only
+	gteWhiteTime     = "wtime"       // white has x msec left on the clock
+	gteBlackTime     = "btime"       // black has x msec left on the clock
+	gteWhiteInc      = "winc"        // white increment per move in mseconds
+	gteBlackInc      = "binc"        //	black increment per move in mseconds
+	gteMovesToGo     = "movestogo"   // there are x moves to the next time control
+	gteStop          = "stop"        // stop calculating as soon as possible
+	gtePonderHit     = "ponderhit"   // the user has played the expected move
+	gteQuit          = "quit"        // quit the program as soon as possible
 )
 
 // NewParser returns a new parser.
@@ -55,6 +64,10 @@ type Parser struct {
 	commandch chan<- Command
 	stopch    chan<- struct{}
 	reader    io.RuneScanner
+
+	// ponderhitch is closed on "ponderhit" to tell the pondering search that
+	// it should start its clock. It's nil unless we're pondering.
+	ponderhitch chan struct{}
 }
 
 // ParseInput starts the parser.
@@ -114,6 +127,8 @@ func waitingForCommand(p *Parser) statefn { // TODO: "running"
 	switch token {
 	case gteIsReady:
 		return p.emit(CommandIsReady{}, waitingForCommand)
+	case gteSetOption:
+		return commandSetOption
 	case gteNewGame:
 		return p.emit(CommandNewGame{}, waitingForCommand)
 	case gtePosition:
@@ -124,6 +139,8 @@ func waitingForCommand(p *Parser) statefn { // TODO: "running"
 		return commandQuit
 	case gteStop:
 		return p.emitStop(waitingForCommand)
+	case gtePonderHit:
+		return p.emitPonderHit(waitingForCommand)
 	case "":
 		return eol(p, waitingForCommand)
 	default:
@@ -142,6 +159,65 @@ func (p *Parser) emit(cmd Command, next statefn) statefn {
 	}
 }
 
+func commandSetOption(p *Parser) statefn {
+	p.logger.Println("command: setoption")
+
+	token, err := nextToken(p.reader)
+	if err != nil {
+		return errorScanning(p, err)
+	}
+
+	switch token {
+	case gteName:
+		return commandSetOptionName
+	case "": // newline
+		return eol(p, waitingForCommand)
+	default:
+		return errorUnrecognized(p, token, commandSetOption)
+	}
+}
+
+func commandSetOptionName(p *Parser) statefn {
+	p.logger.Println("command: setoption name")
+
+	// names may contain spaces, so the name is every token up until "value"
+	// or the end of the line
+	var tokens []string
+	for {
+		token, err := nextToken(p.reader)
+		if err != nil {
+			return errorScanning(p, err)
+		}
+
+		switch token {
+		case gteValue:
+			return commandSetOptionValue(p, strings.Join(tokens, " "))
+		case "": // newline
+			// no value, e.g. for a button
+			p.commandch <- CommandSetOption{Name: strings.Join(tokens, " ")}
+			return eol(p, waitingForCommand)
+		default:
+			tokens = append(tokens, token)
+		}
+	}
+}
+
+func commandSetOptionValue(p *Parser, name string) statefn {
+	p.logger.Println("command: setoption value")
+
+	// values may contain spaces too, so the value is the rest of the line
+	if err := consume(p.reader, isSpace); err != nil {
+		return errorScanning(p, err)
+	}
+	value, err := readLine(p.reader)
+	if err != nil {
+		return errorScanning(p, err)
+	}
+
+	p.commandch <- CommandSetOption{Name: name, Value: value}
+	return eol(p, waitingForCommand)
+}
+
 func commandPosition(p *Parser) statefn {
 	p.logger.Println("command: position")
 
@@ -182,7 +258,7 @@ func commandPositionFEN(p *Parser) statefn {
 	valids := []string{
 		"/12345678BKNPQRbknpqr",     // ranks
 		"wb",                        // to play
-		"KQkq",                      // castling
+		"-KQkqABCDEFGHabcdefgh",     // castling (including Shredder-FEN)
 		"-ABCDEFGHabcdefgh12345678", // en passant
 		"1234567890",                // half moves
 		"1234567890",                // full moves
@@ -252,16 +328,34 @@ func commandPositionMovesMove(p *Parser, partial AppendMoveCommand) statefn {
 type TimeControl struct {
 	WhiteTime, BlackTime           time.Duration
 	WhiteIncrement, BlackIncrement time.Duration
+	MovesToGo                      uint // 0 if there's no next time control ("sudden death")
 }
 
 func (tc TimeControl) String() string {
-	return fmt.Sprintf(
+	s := fmt.Sprintf(
 		"wtime %d btime %d winc %d binc %d",
 		tc.WhiteTime.Milliseconds(),
 		tc.BlackTime.Milliseconds(),
 		tc.WhiteIncrement.Milliseconds(),
 		tc.BlackIncrement.Milliseconds(),
 	)
+	if tc.MovesToGo > 0 {
+		s += fmt.Sprintf(" movestogo %d", tc.MovesToGo)
+	}
+	return s
+}
+
+// goParameters accumulates the parameters for a go command, which may be given
+// together in any order.
+type goParameters struct {
+	TimeControl
+	moveTime    time.Duration
+	mate        uint8
+	depth       uint8
+	nodes       uint64
+	infinite    bool
+	ponder      bool
+	searchMoves []chess.FromToPromoter
 }
 
 func commandGo(p *Parser) statefn {
@@ -272,37 +366,35 @@ func commandGo(p *Parser) statefn {
 		return errorScanning(p, err)
 	}
 
-	switch token {
-	case gteGoDepth:
-		return commandGoDepth
-	case gteGoInfinite:
-		return p.emit(CommandGoInfinite{}, eol(p, waitingForCommand))
-	case gteGoNodes:
-		return commandGoNodes
-	case gteBlackTime:
-		return commandGoTimeBlackTime(p, TimeControl{})
-	case gteWhiteTime:
-		return commandGoTimeWhiteTime(p, TimeControl{})
-	case gteBlackInc:
-		return commandGoTimeBlackIncrement(p, TimeControl{})
-	case gteWhiteInc:
-		return commandGoTimeWhiteIncrement(p, TimeControl{})
-	case "": // newline
+	if token == "" { // newline
 		return eol(p, waitingForCommand)
-	default:
-		return errorUnrecognized(p, token, commandGo)
 	}
+	return commandGoParameter(p, goParameters{}, token)
 }
 
-func commandGoTime(p *Parser, accumulator TimeControl) statefn {
-	p.logger.Println("command: go time")
+func commandGoParameters(p *Parser, accumulator goParameters) statefn {
+	p.logger.Println("command: go parameters")
 
 	token, err := nextToken(p.reader)
 	if err != nil {
 		return errorScanning(p, err)
 	}
 
+	return commandGoParameter(p, accumulator, token)
+}
+
+// commandGoParameter transitions to the state for the go parameter token.
+func commandGoParameter(p *Parser, accumulator goParameters, token string) statefn {
 	switch token {
+	case gteGoDepth:
+		return commandGoDepth(p, accumulator)
+	case gteGoNodes:
+		return commandGoNodes(p, accumulator)
+	case gteGoInfinite:
+		accumulator.infinite = true
+		return commandGoParameters(p, accumulator)
+	case gteGoSearchMoves:
+		return commandGoSearchMoves(p, accumulator)
 	case gteBlackTime:
 		return commandGoTimeBlackTime(p, accumulator)
 	case gteWhiteTime:
@@ -311,86 +403,197 @@ func commandGoTime(p *Parser, accumulator TimeControl) statefn {
 		return commandGoTimeBlackIncrement(p, accumulator)
 	case gteWhiteInc:
 		return commandGoTimeWhiteIncrement(p, accumulator)
+	case gteMovesToGo:
+		return commandGoTimeMovesToGo(p, accumulator)
+	case gteGoMoveTime:
+		return commandGoTimeMoveTime(p, accumulator)
+	case gteGoMate:
+		return commandGoTimeMate(p, accumulator)
+	case gteGoPonder:
+		return commandGoTimePonder(p, accumulator)
 	case "": // newline
-		// command finished, so run it
-		// TODO: check we have at least white and black time set
-		p.commandch <- CommandGoTime{accumulator}
-		return eol(p, waitingForCommand)
+		return commandGoRun(p, accumulator)
 	default:
-		return errorUnrecognized(p, token, commandGoTime(p, accumulator))
+		return errorUnrecognized(p, token, commandGoParameters(p, accumulator))
 	}
 }
 
-func commandGoTimeWhiteTime(p *Parser, accumulator TimeControl) statefn {
+// commandGoRun runs the go command once all of its parameters have been read.
+// Pondering takes precedence, then searching until told to stop, searching for
+// mate, searching to a depth, and searching a number of nodes; an exact move
+// time takes precedence over the clock. Without any limit we search until told
+// to stop.
+func commandGoRun(p *Parser, accumulator goParameters) statefn {
+	moves := accumulator.searchMoves
+	// TODO: check we have at least white and black time set
+	switch {
+	case accumulator.ponder:
+		p.ponderhitch = make(chan struct{})
+		p.commandch <- CommandGoPonder{accumulator.moveTime, accumulator.TimeControl, p.ponderhitch, moves}
+	case accumulator.infinite:
+		p.commandch <- CommandGoInfinite{moves}
+	case accumulator.mate > 0:
+		p.commandch <- CommandGoMate{accumulator.mate, accumulator.moveTime, accumulator.TimeControl, moves}
+	case accumulator.depth > 0:
+		p.commandch <- CommandGoDepth{accumulator.depth, moves}
+	case accumulator.nodes > 0:
+		p.commandch <- CommandGoNodes{accumulator.nodes, moves}
+	case accumulator.moveTime > 0:
+		p.commandch <- CommandGoMoveTime{accumulator.moveTime, moves}
+	case accumulator.TimeControl != TimeControl{}:
+		p.commandch <- CommandGoTime{accumulator.TimeControl, moves}
+	default:
+		p.commandch <- CommandGoInfinite{moves}
+	}
+	return eol(p, waitingForCommand)
+}
+
+// commandGoInvalid abandons a go command with a parameter we can't parse,
+// skipping the rest of the line. Searching without the limit that was asked
+// for (or with some other limit in its place) could run forever.
+func commandGoInvalid(p *Parser) statefn {
+	p.logger.Println("command: go invalid")
+
+	if _, err := readLine(p.reader); err != nil {
+		return errorScanning(p, err)
+	}
+	return eol(p, waitingForCommand)
+}
+
+func commandGoTimeWhiteTime(p *Parser, accumulator goParameters) statefn {
 	p.logger.Println("command: go time white time")
 
 	t, err := nextTokenUint(p.reader, 64)
 	if err != nil {
-		return p.handleError(err, false, commandGoTimeWhiteTime(p, accumulator))
+		return p.handleError(err, false, commandGoInvalid)
 	}
 
 	accumulator.WhiteTime = time.Duration(t) * time.Millisecond
-	return commandGoTime(p, accumulator)
+	return commandGoParameters(p, accumulator)
 }
 
-func commandGoTimeBlackTime(p *Parser, accumulator TimeControl) statefn {
+func commandGoTimeBlackTime(p *Parser, accumulator goParameters) statefn {
 	p.logger.Println("command: go time black time")
 
 	t, err := nextTokenUint(p.reader, 64)
 	if err != nil {
-		return p.handleError(err, false, commandGoTimeBlackTime(p, accumulator))
+		return p.handleError(err, false, commandGoInvalid)
 	}
 
 	accumulator.BlackTime = time.Duration(t) * time.Millisecond
-	return commandGoTime(p, accumulator)
+	return commandGoParameters(p, accumulator)
 }
 
-func commandGoTimeWhiteIncrement(p *Parser, accumulator TimeControl) statefn {
+func commandGoTimeWhiteIncrement(p *Parser, accumulator goParameters) statefn {
 	p.logger.Println("command: go time white increment")
 
 	t, err := nextTokenUint(p.reader, 64)
 	if err != nil {
-		return p.handleError(err, false, commandGoTimeWhiteIncrement(p, accumulator))
+		return p.handleError(err, false, commandGoInvalid)
 	}
 
 	accumulator.WhiteIncrement = time.Duration(t) * time.Millisecond
-	return commandGoTime(p, accumulator)
+	return commandGoParameters(p, accumulator)
 }
 
-func commandGoTimeBlackIncrement(p *Parser, accumulator TimeControl) statefn {
+func commandGoTimeBlackIncrement(p *Parser, accumulator goParameters) statefn {
 	p.logger.Println("command: go time black increment")
 
 	t, err := nextTokenUint(p.reader, 64)
 	if err != nil {
-		return p.handleError(err, false, commandGoTimeBlackIncrement(p, accumulator))
+		return p.handleError(err, false, commandGoInvalid)
 	}
 
 	accumulator.BlackIncrement = time.Duration(t) * time.Millisecond
-	return commandGoTime(p, accumulator)
+	return commandGoParameters(p, accumulator)
+}
+
+func commandGoTimeMovesToGo(p *Parser, accumulator goParameters) statefn {
+	p.logger.Println("command: go time moves to go")
+
+	n, err := nextTokenUint(p.reader, 32)
+	if err != nil {
+		return p.handleError(err, false, commandGoInvalid)
+	}
+
+	accumulator.MovesToGo = uint(n)
+	return commandGoParameters(p, accumulator)
+}
+
+func commandGoTimeMoveTime(p *Parser, accumulator goParameters) statefn {
+	p.logger.Println("command: go time move time")
+
+	t, err := nextTokenUint(p.reader, 64)
+	if err != nil {
+		return p.handleError(err, false, commandGoInvalid)
+	}
+
+	accumulator.moveTime = time.Duration(t) * time.Millisecond
+	return commandGoParameters(p, accumulator)
 }
 
-func commandGoDepth(p *Parser) statefn {
+func commandGoTimeMate(p *Parser, accumulator goParameters) statefn {
+	p.logger.Println("command: go time mate")
+
+	moves, err := nextTokenUint(p.reader, 8)
+	if err != nil {
+		return p.handleError(err, false, commandGoInvalid)
+	}
+
+	accumulator.mate = uint8(moves)
+	return commandGoParameters(p, accumulator)
+}
+
+func commandGoTimePonder(p *Parser, accumulator goParameters) statefn {
+	p.logger.Println("command: go time ponder")
+
+	accumulator.ponder = true
+	return commandGoParameters(p, accumulator)
+}
+
+func commandGoDepth(p *Parser, accumulator goParameters) statefn {
 	p.logger.Println("command: go depth")
 
 	plies, err := nextTokenUint(p.reader, 8)
 	if err != nil {
-		return p.handleError(err, false, commandGoDepth)
+		return p.handleError(err, false, commandGoInvalid)
 	}
 
-	p.commandch <- CommandGoDepth{uint8(plies)}
-	return waitingForCommand
+	accumulator.depth = uint8(plies)
+	return commandGoParameters(p, accumulator)
 }
 
-func commandGoNodes(p *Parser) statefn {
+func commandGoNodes(p *Parser, accumulator goParameters) statefn {
 	p.logger.Println("command: go nodes")
 
 	nodes, err := nextTokenUint(p.reader, 64)
 	if err != nil {
-		return p.handleError(err, false, commandGoNodes)
+		return p.handleError(err, false, commandGoInvalid)
 	}
 
-	p.commandch <- CommandGoNodes{nodes}
-	return waitingForCommand
+	accumulator.nodes = nodes
+	return commandGoParameters(p, accumulator)
+}
+
+func commandGoSearchMoves(p *Parser, accumulator goParameters) statefn {
+	p.logger.Println("command: go searchmoves")
+
+	// moves run until the first token that isn't a move, which is either the
+	// next parameter or the end of the line
+	for {
+		token, err := nextToken(p.reader)
+		if err != nil {
+			return errorScanning(p, err)
+		}
+
+		move, err := ParseUCIN(token)
+		if err != nil {
+			return commandGoParameter(p, accumulator, token)
+		}
+		if move != nil { // null move
+			accumulator.searchMoves = append(accumulator.searchMoves, move)
+		}
+	}
 }
 
 func commandQuit(p *Parser) statefn {
@@ -401,6 +604,20 @@ func commandQuit(p *Parser) statefn {
 func (p *Parser) emitStop(next statefn) statefn {
 	p.logger.Println("stopping current command")
 	p.stopch <- struct{}{}
+	p.ponderhitch = nil // any pondering search is over
+	return next
+}
+
+// emitPonderHit tells the pondering search, if there is one, that the user has
+// played the expected move.
+func (p *Parser) emitPonderHit(next statefn) statefn {
+	if p.ponderhitch == nil {
+		p.logger.Println("cannot ponderhit; not pondering")
+		return next
+	}
+	p.logger.Println("ponderhit")
+	close(p.ponderhitch)
+	p.ponderhitch = nil
 	return next
 }
 
@@ -530,7 +747,7 @@ func nextTokenUint(r io.RuneScanner, bits int) (uint64, error) {
 		return 0, err
 	}
 
-	n, err := strconv.ParseUint(token, 10, 64)
+	n, err := strconv.ParseUint(token, 10, bits)
 	if err != nil {
 		return 0, err
 	}
@@ -538,6 +755,23 @@ func nextTokenUint(r io.RuneScanner, bits int) (uint64, error) {
 	return n, nil
 }
 
+// readLine reads runes from r until the end of the line, returning the runes
+// that were read with any trailing spaces trimmed. The newline is not consumed.
+func readLine(r io.RuneScanner) (string, error) {
+	var buf bytes.Buffer
+	for {
+		c, _, err := r.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		if isEOL(c) {
+			r.UnreadRune()
+			return strings.TrimRightFunc(buf.String(), isSpace), nil
+		}
+		buf.WriteRune(c)
+	}
+}
+
 // readToken reads runes from r until it finds a rune that terminates the token,
 // returning a buffer of runes that were read.
 func readToken(r io.RuneScanner) (string, error) {
@@ -59,6 +59,38 @@ func TestParseInput(t *testing.T) {
 				},
 			},
 		},
+		{
+			"position fen without castling",
+			[]string{
+				"uci", "ucinewgame",
+				"position fen 8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+				"quit",
+			},
+			[]uci.Command{
+				uci.CommandUCI{},
+				uci.CommandNewGame{},
+				&uci.CommandSetPositionFEN{
+					FEN:   "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+					Moves: nil,
+				},
+			},
+		},
+		{
+			"position fen chess960",
+			[]string{
+				"uci", "ucinewgame",
+				"position fen rk2r3/8/8/8/8/8/8/RK2R3 w EAea - 0 1 moves b1e1",
+				"quit",
+			},
+			[]uci.Command{
+				uci.CommandUCI{},
+				uci.CommandNewGame{},
+				&uci.CommandSetPositionFEN{
+					FEN:   "rk2r3/8/8/8/8/8/8/RK2R3 w EAea - 0 1",
+					Moves: []chess.FromToPromoter{mustParseMove("b1e1")},
+				},
+			},
+		},
 		{
 			"position startpos",
 			[]string{"uci", "ucinewgame", "position startpos", "quit"},
@@ -86,6 +118,42 @@ func TestParseInput(t *testing.T) {
 				},
 			},
 		},
+		{
+			"setoption",
+			[]string{
+				"uci",
+				"setoption name Hash value 128",
+				"setoption name Clear Hash",
+				"setoption name NalimovPath value c:\\chess\\tb\\4;c:\\chess tb\\5  ",
+				"setoption name UCI_ShowCurrLine value true",
+				"quit",
+			},
+			[]uci.Command{
+				uci.CommandUCI{},
+				uci.CommandSetOption{Name: "Hash", Value: "128"},
+				uci.CommandSetOption{Name: "Clear Hash"},
+				uci.CommandSetOption{Name: "NalimovPath", Value: "c:\\chess\\tb\\4;c:\\chess tb\\5"},
+				uci.CommandSetOption{Name: "UCI_ShowCurrLine", Value: "true"},
+			},
+		},
+		{
+			"setoption then isready",
+			[]string{
+				"uci",
+				"setoption name Hash value 128",
+				"isready",
+				"setoption name Clear Hash",
+				"isready",
+				"quit",
+			},
+			[]uci.Command{
+				uci.CommandUCI{},
+				uci.CommandSetOption{Name: "Hash", Value: "128"},
+				uci.CommandIsReady{},
+				uci.CommandSetOption{Name: "Clear Hash"},
+				uci.CommandIsReady{},
+			},
+		},
 		{
 			"go depth",
 			[]string{
@@ -120,7 +188,112 @@ func TestParseInput(t *testing.T) {
 			[]uci.Command{
 				uci.CommandUCI{}, uci.CommandNewGame{}, &uci.CommandSetStartingPosition{},
 				uci.CommandGoTime{
-					uci.TimeControl{
+					TimeControl: uci.TimeControl{
+						WhiteTime:      1 * time.Minute,
+						BlackTime:      2 * time.Minute,
+						WhiteIncrement: 1 * time.Second,
+						BlackIncrement: 2 * time.Second,
+					},
+				},
+			},
+		},
+		{
+			"go time movestogo",
+			[]string{
+				"uci", "ucinewgame", "position startpos",
+				"go wtime 300000 btime 300000 movestogo 40",
+				"quit",
+			},
+			[]uci.Command{
+				uci.CommandUCI{}, uci.CommandNewGame{}, &uci.CommandSetStartingPosition{},
+				uci.CommandGoTime{
+					TimeControl: uci.TimeControl{
+						WhiteTime: 5 * time.Minute,
+						BlackTime: 5 * time.Minute,
+						MovesToGo: 40,
+					},
+				},
+			},
+		},
+		{
+			"go movestogo first",
+			[]string{
+				"uci", "ucinewgame", "position startpos",
+				"go movestogo 1 btime 500 wtime 1000",
+				"quit",
+			},
+			[]uci.Command{
+				uci.CommandUCI{}, uci.CommandNewGame{}, &uci.CommandSetStartingPosition{},
+				uci.CommandGoTime{
+					TimeControl: uci.TimeControl{
+						WhiteTime: 1 * time.Second,
+						BlackTime: 500 * time.Millisecond,
+						MovesToGo: 1,
+					},
+				},
+			},
+		},
+		{
+			"go movetime",
+			[]string{
+				"uci", "ucinewgame", "position startpos",
+				"go movetime 2500",
+				"quit",
+			},
+			[]uci.Command{
+				uci.CommandUCI{}, uci.CommandNewGame{}, &uci.CommandSetStartingPosition{},
+				uci.CommandGoMoveTime{MoveTime: 2500 * time.Millisecond},
+			},
+		},
+		{
+			"go movetime with time",
+			[]string{
+				"uci", "ucinewgame", "position startpos",
+				"go wtime 60000 btime 120000 movetime 500 winc 1000",
+				"quit",
+			},
+			[]uci.Command{
+				uci.CommandUCI{}, uci.CommandNewGame{}, &uci.CommandSetStartingPosition{},
+				uci.CommandGoMoveTime{MoveTime: 500 * time.Millisecond},
+			},
+		},
+		{
+			"go mate",
+			[]string{
+				"uci", "ucinewgame", "position startpos",
+				"go mate 3",
+				"quit",
+			},
+			[]uci.Command{
+				uci.CommandUCI{}, uci.CommandNewGame{}, &uci.CommandSetStartingPosition{},
+				uci.CommandGoMate{Moves: 3},
+			},
+		},
+		{
+			"go mate out of range",
+			[]string{
+				"uci", "ucinewgame", "position startpos",
+				"go mate 300",
+				"isready",
+				"quit",
+			},
+			[]uci.Command{
+				uci.CommandUCI{}, uci.CommandNewGame{}, &uci.CommandSetStartingPosition{},
+				uci.CommandIsReady{},
+			},
+		},
+		{
+			"go mate with time",
+			[]string{
+				"uci", "ucinewgame", "position startpos",
+				"go mate 5 wtime 60000 btime 120000 winc 1000 binc 2000",
+				"quit",
+			},
+			[]uci.Command{
+				uci.CommandUCI{}, uci.CommandNewGame{}, &uci.CommandSetStartingPosition{},
+				uci.CommandGoMate{
+					Moves: 5,
+					TimeControl: uci.TimeControl{
 						WhiteTime:      1 * time.Minute,
 						BlackTime:      2 * time.Minute,
 						WhiteIncrement: 1 * time.Second,
@@ -129,6 +302,53 @@ func TestParseInput(t *testing.T) {
 				},
 			},
 		},
+		{
+			"go mate with movetime",
+			[]string{
+				"uci", "ucinewgame", "position startpos",
+				"go btime 1000 wtime 2000 mate 2 movetime 300",
+				"quit",
+			},
+			[]uci.Command{
+				uci.CommandUCI{}, uci.CommandNewGame{}, &uci.CommandSetStartingPosition{},
+				uci.CommandGoMate{
+					Moves:    2,
+					MoveTime: 300 * time.Millisecond,
+					TimeControl: uci.TimeControl{
+						WhiteTime: 2 * time.Second,
+						BlackTime: 1 * time.Second,
+					},
+				},
+			},
+		},
+		{
+			"go without limits then isready",
+			[]string{
+				"uci", "ucinewgame", "position startpos",
+				"go searchmoves b1c3",
+				"isready",
+				"quit",
+			},
+			[]uci.Command{
+				uci.CommandUCI{}, uci.CommandNewGame{}, &uci.CommandSetStartingPosition{},
+				uci.CommandGoInfinite{SearchMoves: []chess.FromToPromoter{mustParseMove("b1c3")}},
+				uci.CommandIsReady{},
+			},
+		},
+		{
+			"go infinite then isready",
+			[]string{
+				"uci", "ucinewgame", "position startpos",
+				"go infinite",
+				"isready",
+				"quit",
+			},
+			[]uci.Command{
+				uci.CommandUCI{}, uci.CommandNewGame{}, &uci.CommandSetStartingPosition{},
+				uci.CommandGoInfinite{},
+				uci.CommandIsReady{},
+			},
+		},
 		{
 			"go infinite",
 			[]string{
@@ -141,6 +361,78 @@ func TestParseInput(t *testing.T) {
 				uci.CommandGoInfinite{},
 			},
 		},
+		{
+			"go searchmoves depth",
+			[]string{
+				"uci", "ucinewgame", "position startpos",
+				"go searchmoves e2e4 d2d4 depth 5",
+				"quit",
+			},
+			[]uci.Command{
+				uci.CommandUCI{}, uci.CommandNewGame{}, &uci.CommandSetStartingPosition{},
+				uci.CommandGoDepth{
+					Plies:       5,
+					SearchMoves: []chess.FromToPromoter{mustParseMove("e2e4"), mustParseMove("d2d4")},
+				},
+			},
+		},
+		{
+			"go depth searchmoves",
+			[]string{
+				"uci", "ucinewgame", "position startpos",
+				"go depth 5 searchmoves g1f3",
+				"quit",
+			},
+			[]uci.Command{
+				uci.CommandUCI{}, uci.CommandNewGame{}, &uci.CommandSetStartingPosition{},
+				uci.CommandGoDepth{
+					Plies:       5,
+					SearchMoves: []chess.FromToPromoter{mustParseMove("g1f3")},
+				},
+			},
+		},
+		{
+			"go infinite searchmoves",
+			[]string{
+				"uci", "ucinewgame", "position fen 4k3/1P6/8/8/8/8/8/4K3 w - - 0 1",
+				"go infinite searchmoves b7b8q b7b8n",
+				"quit",
+			},
+			[]uci.Command{
+				uci.CommandUCI{}, uci.CommandNewGame{},
+				&uci.CommandSetPositionFEN{FEN: "4k3/1P6/8/8/8/8/8/4K3 w - - 0 1"},
+				uci.CommandGoInfinite{
+					SearchMoves: []chess.FromToPromoter{mustParseMove("b7b8q"), mustParseMove("b7b8n")},
+				},
+			},
+		},
+		{
+			"go time searchmoves",
+			[]string{
+				"uci", "ucinewgame", "position startpos",
+				"go wtime 1000 searchmoves a2a3 btime 2000",
+				"quit",
+			},
+			[]uci.Command{
+				uci.CommandUCI{}, uci.CommandNewGame{}, &uci.CommandSetStartingPosition{},
+				uci.CommandGoTime{
+					TimeControl: uci.TimeControl{WhiteTime: 1 * time.Second, BlackTime: 2 * time.Second},
+					SearchMoves: []chess.FromToPromoter{mustParseMove("a2a3")},
+				},
+			},
+		},
+		{
+			"go searchmoves only",
+			[]string{
+				"uci", "ucinewgame", "position startpos",
+				"go searchmoves b1c3",
+				"quit",
+			},
+			[]uci.Command{
+				uci.CommandUCI{}, uci.CommandNewGame{}, &uci.CommandSetStartingPosition{},
+				uci.CommandGoInfinite{SearchMoves: []chess.FromToPromoter{mustParseMove("b1c3")}},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -154,26 +446,132 @@ func TestParseInput(t *testing.T) {
 			go p.ParseInput()
 
 			var commands []uci.Command
+			done := make(chan struct{})
 			go func() {
+				defer close(done)
 				for cmd := range commandch {
 					commands = append(commands, cmd)
 				}
 			}()
 
 			for _, str := range tt.input {
+				time.Sleep(processing) // let the collector start, and the previous command finish
 				pipew.Write([]byte(str + "\n"))
-				time.Sleep(processing)
 			}
 
-			assert.Equal(t, tt.expected, commands)
-
 			// should be no more commands, and the channel should be closed
 			select {
-			case cmd, open := <-commandch:
-				assert.Equal(t, nil, cmd)
-				assert.False(t, open)
-			default:
-				t.Errorf("channel is still open") // closed channel wouldn't block
+			case <-done:
+			case <-time.After(timeout):
+				t.Fatal("channel is still open")
+			}
+
+			assert.Equal(t, tt.expected, commands)
+		})
+	}
+}
+
+func TestParseGoPonder(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		ponderhit bool
+		expected  uci.CommandGoPonder // ignoring PonderHit
+	}{
+		{
+			"go ponder",
+			"go ponder",
+			true,
+			uci.CommandGoPonder{},
+		},
+		{
+			"go ponder with clock",
+			"go ponder wtime 2000 btime 1000 winc 10 binc 20",
+			true,
+			uci.CommandGoPonder{
+				TimeControl: uci.TimeControl{
+					WhiteTime:      2 * time.Second,
+					BlackTime:      1 * time.Second,
+					WhiteIncrement: 10 * time.Millisecond,
+					BlackIncrement: 20 * time.Millisecond,
+				},
+			},
+		},
+		{
+			"go ponder after clock",
+			"go wtime 2000 btime 1000 movestogo 5 ponder",
+			true,
+			uci.CommandGoPonder{
+				TimeControl: uci.TimeControl{WhiteTime: 2 * time.Second, BlackTime: 1 * time.Second, MovesToGo: 5},
+			},
+		},
+		{
+			"go ponder with move time",
+			"go ponder movetime 300",
+			true,
+			uci.CommandGoPonder{MoveTime: 300 * time.Millisecond},
+		},
+		{
+			"stopped",
+			"go ponder wtime 2000 btime 1000",
+			false,
+			uci.CommandGoPonder{
+				TimeControl: uci.TimeControl{WhiteTime: 2 * time.Second, BlackTime: 1 * time.Second},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			piper, pipew := io.Pipe()
+
+			p, commandch, stopch := uci.NewParser(piper, os.Stdout)
+			go p.ParseInput()
+
+			input := []string{"uci", "ucinewgame", "position startpos", tt.input, "stop", "quit"}
+			if tt.ponderhit {
+				input[4] = "ponderhit"
+			}
+			go func() {
+				for _, str := range input {
+					pipew.Write([]byte(str + "\n"))
+					time.Sleep(processing)
+				}
+			}()
+
+			var ponder uci.CommandGoPonder
+			for cmd := range commandch {
+				if c, ok := cmd.(uci.CommandGoPonder); ok {
+					ponder = c
+					break
+				}
+			}
+			require.NotNil(t, ponder.PonderHit)
+
+			// the ponder hit channel is closed on "ponderhit" only
+			if tt.ponderhit {
+				select {
+				case <-ponder.PonderHit:
+				case <-time.After(timeout):
+					t.Errorf("ponder hit channel wasn't closed")
+				}
+			} else {
+				select {
+				case <-stopch:
+				case <-time.After(timeout):
+					t.Errorf("didn't stop")
+				}
+				select {
+				case <-ponder.PonderHit:
+					t.Errorf("ponder hit channel was closed")
+				default:
+				}
+			}
+
+			ponder.PonderHit = nil
+			assert.Equal(t, tt.expected, ponder)
+
+			for range commandch {
+				// drain until quit
 			}
 		})
 	}
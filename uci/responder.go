@@ -6,6 +6,7 @@ import (
 	"log"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/GeorgeBills/chess"
 )
@@ -18,7 +19,9 @@ const (
 	etgUCIOK    = "uciok"    // the engine has sent all infos and is ready
 	etgReadyOK  = "readyok"  // the engine is ready to accept new commands
 	etgBestMove = "bestmove" // engine has stopped searching and found the best move
+	etgPonder   = "ponder"   // the move the engine would like to ponder on
 	etgInfo     = "info"     // engine wants to send information to the GUI
+	etgOption   = "option"   // tells the GUI which parameters can be changed in the engine
 )
 
 type Response interface {
@@ -65,19 +68,115 @@ type ResponseIsReady struct{}
 
 func (r ResponseIsReady) Response() string { return etgReadyOK }
 
+// ResponseBestMove reports the best move found by the search. Ponder is the
+// move the engine expects the opponent to reply with, and is left out of the
+// response if it's nil.
 type ResponseBestMove struct {
-	Move chess.FromToPromoter
+	Move   chess.FromToPromoter
+	Ponder chess.FromToPromoter
 }
 
 func (r ResponseBestMove) Response() string {
-	movestr := ToUCIN(r.Move)
-	return strings.Join([]string{etgBestMove, movestr}, " ")
+	parts := []string{etgBestMove, ToUCIN(r.Move)}
+	if r.Ponder != nil {
+		parts = append(parts, etgPonder, ToUCIN(r.Ponder))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Score is the engine's evaluation of a position, from its own perspective.
+type Score struct {
+	Centipawns int // used if Mate is 0
+	Mate       int // moves until mate; negative if the engine is being mated
+}
+
+func (s Score) String() string {
+	if s.Mate != 0 {
+		return "mate " + strconv.Itoa(s.Mate)
+	}
+	return "cp " + strconv.Itoa(s.Centipawns)
 }
 
+// ResponseSearchInformation reports on the progress of the search. Zero valued
+// fields (other than Depth) are left out of the response.
 type ResponseSearchInformation struct {
-	Depth uint8
+	Depth              uint8
+	SelectiveDepth     uint8
+	Score              *Score
+	Nodes              uint64
+	NodesPerSecond     uint64
+	Time               time.Duration
+	HashFull           int // permille
+	CurrentMove        chess.FromToPromoter
+	CurrentMoveNumber  int
+	MultiPV            int // the 1-indexed rank of this line, in multi PV mode
+	PrincipalVariation []chess.FromToPromoter
 }
 
 func (r ResponseSearchInformation) Response() string {
-	return strings.Join([]string{etgInfo, "depth", strconv.Itoa(int(r.Depth))}, " ")
+	parts := []string{etgInfo, "depth", strconv.Itoa(int(r.Depth))}
+	if r.SelectiveDepth > 0 {
+		parts = append(parts, "seldepth", strconv.Itoa(int(r.SelectiveDepth)))
+	}
+	if r.MultiPV > 0 {
+		parts = append(parts, "multipv", strconv.Itoa(r.MultiPV))
+	}
+	if r.Score != nil {
+		parts = append(parts, "score", r.Score.String())
+	}
+	if r.Nodes > 0 {
+		parts = append(parts, "nodes", strconv.FormatUint(r.Nodes, 10))
+	}
+	if r.NodesPerSecond > 0 {
+		parts = append(parts, "nps", strconv.FormatUint(r.NodesPerSecond, 10))
+	}
+	if r.Time > 0 {
+		parts = append(parts, "time", strconv.FormatInt(r.Time.Milliseconds(), 10))
+	}
+	if r.HashFull > 0 {
+		parts = append(parts, "hashfull", strconv.Itoa(r.HashFull))
+	}
+	if r.CurrentMove != nil {
+		parts = append(parts, "currmove", ToUCIN(r.CurrentMove))
+	}
+	if r.CurrentMoveNumber > 0 {
+		parts = append(parts, "currmovenumber", strconv.Itoa(r.CurrentMoveNumber))
+	}
+	if len(r.PrincipalVariation) > 0 {
+		// pv must be last, since it runs to the end of the line
+		parts = append(parts, "pv")
+		for _, m := range r.PrincipalVariation {
+			parts = append(parts, ToUCIN(m))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+type ResponseOption struct {
+	Option
+}
+
+func (r ResponseOption) Response() string {
+	parts := []string{etgOption, "name", r.Name, "type", string(r.Type)}
+	switch r.Type {
+	case OptionTypeButton:
+		// buttons have no default
+	case OptionTypeString:
+		def := r.Default
+		if def == "" {
+			def = "<empty>"
+		}
+		parts = append(parts, "default", def)
+	default:
+		parts = append(parts, "default", r.Default)
+	}
+	switch r.Type {
+	case OptionTypeSpin:
+		parts = append(parts, "min", strconv.FormatInt(r.Min, 10), "max", strconv.FormatInt(r.Max, 10))
+	case OptionTypeCombo:
+		for _, v := range r.Vars {
+			parts = append(parts, "var", v)
+		}
+	}
+	return strings.Join(parts, " ")
 }
@@ -1,13 +1,16 @@
 package uci_test
 
 import (
-	"bytes"
+	"bufio"
+	"io"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/GeorgeBills/chess"
 	"github.com/GeorgeBills/chess/uci"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestWriteResponses(t *testing.T) {
@@ -36,25 +39,105 @@ func TestWriteResponses(t *testing.T) {
 			uci.ResponseBestMove{Move: mustParseMove("a1h8")},
 			"bestmove a1h8\n",
 		},
+		{
+			"bestmove with ponder",
+			uci.ResponseBestMove{Move: mustParseMove("e2e4"), Ponder: mustParseMove("e7e5")},
+			"bestmove e2e4 ponder e7e5\n",
+		},
+		{
+			"option spin",
+			uci.ResponseOption{uci.Option{Name: "Hash", Type: uci.OptionTypeSpin, Default: "16", Min: 1, Max: 1024}},
+			"option name Hash type spin default 16 min 1 max 1024\n",
+		},
+		{
+			"option check",
+			uci.ResponseOption{uci.Option{Name: "Nullmove", Type: uci.OptionTypeCheck, Default: "true"}},
+			"option name Nullmove type check default true\n",
+		},
+		{
+			"option combo",
+			uci.ResponseOption{uci.Option{Name: "Style", Type: uci.OptionTypeCombo, Default: "Normal", Vars: []string{"Solid", "Normal", "Risky"}}},
+			"option name Style type combo default Normal var Solid var Normal var Risky\n",
+		},
+		{
+			"option button",
+			uci.ResponseOption{uci.Option{Name: "Clear Hash", Type: uci.OptionTypeButton}},
+			"option name Clear Hash type button\n",
+		},
+		{
+			"option string",
+			uci.ResponseOption{uci.Option{Name: "NalimovPath", Type: uci.OptionTypeString}},
+			"option name NalimovPath type string default <empty>\n",
+		},
 		{
 			"info",
 			uci.ResponseSearchInformation{Depth: 123},
 			"info depth 123\n",
 		},
+		{
+			"info with nodes",
+			uci.ResponseSearchInformation{Depth: 5, Nodes: 24_938},
+			"info depth 5 nodes 24938\n",
+		},
+		{
+			"info with score and pv",
+			uci.ResponseSearchInformation{
+				Depth:              7,
+				SelectiveDepth:     15,
+				Score:              &uci.Score{Centipawns: -23},
+				Nodes:              1_234_567,
+				NodesPerSecond:     2_000_000,
+				Time:               617 * time.Millisecond,
+				HashFull:           125,
+				PrincipalVariation: []chess.FromToPromoter{mustParseMove("e2e4"), mustParseMove("e7e5"), mustParseMove("g1f3")},
+			},
+			"info depth 7 seldepth 15 score cp -23 nodes 1234567 nps 2000000 time 617 hashfull 125 pv e2e4 e7e5 g1f3\n",
+		},
+		{
+			"info with mate",
+			uci.ResponseSearchInformation{
+				Depth:              3,
+				Score:              &uci.Score{Mate: 2},
+				PrincipalVariation: []chess.FromToPromoter{mustParseMove("a7a1"), mustParseMove("g7g1"), mustParseMove("a1g1")},
+			},
+			"info depth 3 score mate 2 pv a7a1 g7g1 a1g1\n",
+		},
+		{
+			"info with being mated",
+			uci.ResponseSearchInformation{Depth: 4, Score: &uci.Score{Mate: -3}},
+			"info depth 4 score mate -3\n",
+		},
+		{
+			"info with currmove",
+			uci.ResponseSearchInformation{Depth: 12, CurrentMove: mustParseMove("e7e8q"), CurrentMoveNumber: 3},
+			"info depth 12 currmove e7e8q currmovenumber 3\n",
+		},
+		{
+			"info with multipv",
+			uci.ResponseSearchInformation{
+				Depth:              6,
+				SelectiveDepth:     9,
+				MultiPV:            2,
+				Score:              &uci.Score{Centipawns: 15},
+				PrincipalVariation: []chess.FromToPromoter{mustParseMove("d2d4"), mustParseMove("d7d5")},
+			},
+			"info depth 6 seldepth 9 multipv 2 score cp 15 pv d2d4 d7d5\n",
+		},
 	}
 
 	responsech := make(chan uci.Response)
-	var buf bytes.Buffer
-	r := uci.NewResponder(responsech, &buf, os.Stdout)
+	piper, pipew := io.Pipe()
+	r := uci.NewResponder(responsech, pipew, os.Stdout)
+	lines := bufio.NewReader(piper)
 
 	go r.WriteResponses()
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			responsech <- tt.response
-			time.Sleep(processing)
-			assert.Equal(t, tt.expected, buf.String())
-			buf.Reset()
+			line, err := lines.ReadString('\n')
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, line)
 		})
 	}
 